@@ -19,9 +19,31 @@ const (
 	HEARTBEAT_INTERVAL = 10 * time.Second
 
 	MAX_MSG_SIZE = 1024 * 1024
+
+	// 服务器主动请求(Call)的 Tag 最高位为 1, 与客户端请求的 Tag 区分
+	MSG_CALL_TAG_FLAG uint32 = 1 << 31
+
+	// 加密握手帧的 MsgID, 业务消息不能使用
+	MSG_ID_HANDSHAKE uint16 = 0xFFFF
+
+	// 网关与后端之间的控制帧 MsgID, 业务消息不能使用
+	MSG_ID_GATE_OFFLINE uint16 = 0xFFFE // 网关通知后端用户断开
+	MSG_ID_GATE_KICK    uint16 = 0xFFFD // 后端通知网关踢掉用户
+)
+
+const (
+	SERVER_SHUTDOWN_TIMEOUT = 10 * time.Second
 )
 
 const (
-	CONN_READ_LIMIT    = 1024
+	CONN_READ_LIMIT    = MAX_MSG_SIZE
 	CONN_READ_DEADLINE = 60 * time.Second
+	CONN_DIAL_TIMEOUT  = 5 * time.Second
+
+	// 服务端读取 PROXY 头及 TLS 握手的超时
+	CONN_HANDSHAKE_TIMEOUT = 5 * time.Second
+
+	// 客户端断线重连的初始间隔和最大间隔
+	RECONNECT_MIN_INTERVAL = 1 * time.Second
+	RECONNECT_MAX_INTERVAL = 30 * time.Second
 )
@@ -0,0 +1,13 @@
+package enums
+
+// kcp 默认参数, 使用极速模式
+const (
+	KCP_NO_DELAY = 1
+	KCP_INTERVAL = 10 // 内部刷新间隔(ms)
+	KCP_RESEND   = 2  // 快速重传
+	KCP_NC       = 1  // 关闭拥塞控制
+
+	KCP_SND_WND = 256
+	KCP_RCV_WND = 256
+	KCP_MTU     = 1350
+)
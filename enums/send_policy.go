@@ -0,0 +1,18 @@
+package enums
+
+// SEND_POLICY 会话发送队列满时的处理方式
+type SEND_POLICY int
+
+const (
+	SEND_POLICY_DROP_NEWEST SEND_POLICY = iota // 丢弃新消息
+	SEND_POLICY_DROP_OLDEST                    // 丢弃队列中最旧的消息
+	SEND_POLICY_DISCONNECT                     // 断开消费过慢的连接
+	SEND_POLICY_BLOCK                          // 阻塞等待, 超时后丢弃
+)
+
+const (
+	SESSION_QUEUE_SIZE = 1024
+
+	// 一次合并写入的最大帧数
+	SESSION_WRITE_BATCH = 64
+)
@@ -1,5 +1,7 @@
 package enums
 
+import "time"
+
 const (
 	SERVER_REGISTER int32 = iota + 1
 	SERVER_GATE
@@ -7,3 +9,25 @@ const (
 	SERVER_LOGIN
 	SERVER_CENTER
 )
+
+const (
+	// 网关与后端之间每条转发流的发送队列长度
+	GATE_QUEUE_SIZE = 8192
+)
+
+const (
+	// 注册信息的有效期, 每 1/3 有效期续约一次
+	REGISTRY_TTL = 10 * time.Second
+	// 客户端检查服务列表变化的间隔
+	REGISTRY_WATCH_INTERVAL = 2 * time.Second
+	// grpc 服务发现的 scheme, 如 gc:///3 表示 SERVER_GAME
+	REGISTRY_SCHEME = "gc"
+
+	BALANCER_ROUND_ROBIN     = "round_robin"
+	BALANCER_CONSISTENT_HASH = "gc_consistent_hash" // 按 userID 一致性哈希
+)
+
+const (
+	// 请求 ID 在 grpc metadata 中的 key
+	GRPC_MD_REQUEST_ID = "x-request-id"
+)
@@ -11,12 +11,24 @@ var (
 	ERR_PARAM        = CreateErrCode(3, NewCodeLang("参数错误", enums.LANG_CN), NewCodeLang("The parameter is incorrect", enums.LANG_EN))
 	ERR_CONFIG_NIL   = CreateErrCode(4, NewCodeLang("配置为空", enums.LANG_CN), NewCodeLang("The Config Is Nil", enums.LANG_EN))
 
-	ERR_NET_SEND_TIMEOUT   = CreateErrCode(11, NewCodeLang("发送数据超时", enums.LANG_CN), NewCodeLang("The sending data timed out", enums.LANG_EN))
-	ERR_NET_PKG_LEN_LIMIT  = CreateErrCode(12, NewCodeLang("数据包长度限制", enums.LANG_CN), NewCodeLang("Packet length limit", enums.LANG_EN))
-	ERR_SERVER_INTERNAL    = CreateErrCode(13, NewCodeLang("服务器内部错误", enums.LANG_CN), NewCodeLang("Server internal error", enums.LANG_EN))
-	ERR_WP_TOO_MANY_WORKER = CreateErrCode(14, NewCodeLang("工作池任务太多", enums.LANG_CN), NewCodeLang("There are too many work pool tasks", enums.LANG_EN))
-	ERR_JSON_MARSHAL_ERR   = CreateErrCode(15, NewCodeLang("json打包错误", enums.LANG_CN), NewCodeLang("JSON packaging error", enums.LANG_EN))
-	ERR_JSON_UNMARSHAL_ERR = CreateErrCode(16, NewCodeLang("json解包错误", enums.LANG_CN), NewCodeLang("JSON unpacking error", enums.LANG_EN))
+	ERR_NET_SEND_TIMEOUT      = CreateErrCode(11, NewCodeLang("发送数据超时", enums.LANG_CN), NewCodeLang("The sending data timed out", enums.LANG_EN))
+	ERR_NET_PKG_LEN_LIMIT     = CreateErrCode(12, NewCodeLang("数据包长度限制", enums.LANG_CN), NewCodeLang("Packet length limit", enums.LANG_EN))
+	ERR_SERVER_INTERNAL       = CreateErrCode(13, NewCodeLang("服务器内部错误", enums.LANG_CN), NewCodeLang("Server internal error", enums.LANG_EN))
+	ERR_WP_TOO_MANY_WORKER    = CreateErrCode(14, NewCodeLang("工作池任务太多", enums.LANG_CN), NewCodeLang("There are too many work pool tasks", enums.LANG_EN))
+	ERR_JSON_MARSHAL_ERR      = CreateErrCode(15, NewCodeLang("json打包错误", enums.LANG_CN), NewCodeLang("JSON packaging error", enums.LANG_EN))
+	ERR_JSON_UNMARSHAL_ERR    = CreateErrCode(16, NewCodeLang("json解包错误", enums.LANG_CN), NewCodeLang("JSON unpacking error", enums.LANG_EN))
+	ERR_NET_PKG_INVALID       = CreateErrCode(17, NewCodeLang("数据包格式错误", enums.LANG_CN), NewCodeLang("Invalid packet", enums.LANG_EN))
+	ERR_NET_MSG_NOT_FOUND     = CreateErrCode(18, NewCodeLang("消息未注册", enums.LANG_CN), NewCodeLang("Message not registered", enums.LANG_EN))
+	ERR_NET_NOT_LOGIN         = CreateErrCode(19, NewCodeLang("未登录", enums.LANG_CN), NewCodeLang("Not logged in", enums.LANG_EN))
+	ERR_NET_RATE_LIMIT        = CreateErrCode(20, NewCodeLang("请求过于频繁", enums.LANG_CN), NewCodeLang("Too many requests", enums.LANG_EN))
+	ERR_NET_SESSION_CLOSED    = CreateErrCode(21, NewCodeLang("连接已关闭", enums.LANG_CN), NewCodeLang("Session closed", enums.LANG_EN))
+	ERR_NET_LOGIN_REPLACED    = CreateErrCode(22, NewCodeLang("账号在其他地方登录", enums.LANG_CN), NewCodeLang("Logged in from another location", enums.LANG_EN))
+	ERR_NET_LOGIN_REJECTED    = CreateErrCode(23, NewCodeLang("账号已在线", enums.LANG_CN), NewCodeLang("Account already online", enums.LANG_EN))
+	ERR_NET_KICKED            = CreateErrCode(24, NewCodeLang("被踢下线", enums.LANG_CN), NewCodeLang("Kicked offline", enums.LANG_EN))
+	ERR_NET_RESUME_FAILED     = CreateErrCode(25, NewCodeLang("断线重连失败", enums.LANG_CN), NewCodeLang("Session resume failed", enums.LANG_EN))
+	ERR_NET_HANDSHAKE_FAILED  = CreateErrCode(26, NewCodeLang("加密握手失败", enums.LANG_CN), NewCodeLang("Handshake failed", enums.LANG_EN))
+	ERR_NET_DECRYPT_FAILED    = CreateErrCode(27, NewCodeLang("数据解密失败", enums.LANG_CN), NewCodeLang("Decrypt failed", enums.LANG_EN))
+	ERR_NET_RELAY_UNAVAILABLE = CreateErrCode(28, NewCodeLang("转发服务不可用", enums.LANG_CN), NewCodeLang("Relay unavailable", enums.LANG_EN))
 
 	ERR_EVENT_PARAM_INVALID     = CreateErrCode(31, NewCodeLang("事件参数错误", enums.LANG_CN), NewCodeLang("Event parameter error", enums.LANG_EN))
 	ERR_EVENT_LISTENER_LIMIT    = CreateErrCode(32, NewCodeLang("事件监听器数量限制", enums.LANG_CN), NewCodeLang("Event listener limit", enums.LANG_EN))
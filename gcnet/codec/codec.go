@@ -0,0 +1,126 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec 默认包头 Len(4) MsgID(2) Tag(4) UserID(8), 小端序
+// Len 只记录包体长度
+type Codec struct {
+	maxPacketSize int
+}
+
+func NewCodec(maxPacketSize int) *Codec {
+	if maxPacketSize <= enums.MSG_HEADER_SIZE {
+		maxPacketSize = enums.MSG_MAX_PACKET_SIZE
+	}
+
+	return &Codec{
+		maxPacketSize: maxPacketSize,
+	}
+}
+
+func (c *Codec) HeaderSize() int {
+	return enums.MSG_HEADER_SIZE
+}
+
+func (c *Codec) MaxPacketSize() int {
+	return c.maxPacketSize
+}
+
+func (c *Codec) EncodeHeader(dst []byte, frame *iface.MessageFrame) {
+	binary.LittleEndian.PutUint32(dst[0:4], frame.Len)
+	binary.LittleEndian.PutUint16(dst[4:6], frame.MsgID)
+	binary.LittleEndian.PutUint32(dst[6:10], frame.Tag)
+	binary.LittleEndian.PutUint64(dst[10:18], frame.UserID)
+}
+
+func (c *Codec) DecodeHeader(src []byte, frame *iface.MessageFrame) error {
+	if len(src) < enums.MSG_HEADER_SIZE {
+		return errcode.ERR_NET_PKG_INVALID
+	}
+
+	frame.Len = binary.LittleEndian.Uint32(src[0:4])
+	frame.MsgID = binary.LittleEndian.Uint16(src[4:6])
+	frame.Tag = binary.LittleEndian.Uint32(src[6:10])
+	frame.UserID = binary.LittleEndian.Uint64(src[10:18])
+
+	if int64(frame.Len)+enums.MSG_HEADER_SIZE > int64(c.maxPacketSize) {
+		return errcode.ERR_NET_PKG_LEN_LIMIT
+	}
+
+	return nil
+}
+
+func PackWith(c iface.ICodec, msgID uint16, tag uint32, userID uint64, body []byte) []byte {
+	headerSize := c.HeaderSize()
+	data := make([]byte, headerSize+len(body))
+	c.EncodeHeader(data[:headerSize], &iface.MessageFrame{
+		Len:    uint32(len(body)),
+		MsgID:  msgID,
+		Tag:    tag,
+		UserID: userID,
+	})
+	copy(data[headerSize:], body)
+
+	return data
+}
+
+// UnpackWith 解析一个完整的帧, 返回的 body 引用 data
+func UnpackWith(c iface.ICodec, data []byte) (*iface.MessageFrame, []byte, error) {
+	frame := &iface.MessageFrame{}
+	if err := c.DecodeHeader(data, frame); err != nil {
+		return nil, nil, err
+	}
+
+	end := c.HeaderSize() + int(frame.Len)
+	if len(data) < end {
+		return nil, nil, errcode.ERR_NET_PKG_INVALID
+	}
+
+	return frame, data[c.HeaderSize():end], nil
+}
+
+// SplitWith 用于 bufio.Scanner, 每次返回一个完整的帧(包头+包体)
+func SplitWith(c iface.ICodec) bufio.SplitFunc {
+	headerSize := c.HeaderSize()
+
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) < headerSize {
+			return 0, nil, nil
+		}
+
+		var frame iface.MessageFrame
+		if err = c.DecodeHeader(data[:headerSize], &frame); err != nil {
+			return 0, nil, err
+		}
+
+		n := headerSize + int(frame.Len)
+		if len(data) < n {
+			return 0, nil, nil
+		}
+
+		return n, data[:n], nil
+	}
+}
+
+func PackProtoWith(c iface.ICodec, msgID uint16, tag uint32, userID uint64, msg proto.Message) ([]byte, error) {
+	var body []byte
+	if msg != nil {
+		var err error
+		if body, err = proto.Marshal(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.HeaderSize()+len(body) > c.MaxPacketSize() {
+		return nil, errcode.ERR_NET_PKG_LEN_LIMIT
+	}
+
+	return PackWith(c, msgID, tag, userID, body), nil
+}
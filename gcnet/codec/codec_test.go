@@ -0,0 +1,74 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"io"
+	"testing"
+)
+
+// 每次只读 n 个字节, 模拟 tcp 分段
+type chunkReader struct {
+	data []byte
+	n    int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := r.n
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > len(r.data) {
+		n = len(r.data)
+	}
+	copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestSplit(t *testing.T) {
+	var as = assert.New(t)
+
+	var stream []byte
+	stream = append(stream, Pack(1, 10, 100, []byte("hello"))...)
+	stream = append(stream, Pack(2, 20, 200, nil)...)
+	stream = append(stream, Pack(3, 30, 300, bytes.Repeat([]byte("x"), 1000))...)
+
+	for _, n := range []int{1, 7, 19, len(stream)} {
+		scanner := bufio.NewScanner(&chunkReader{data: stream, n: n})
+		scanner.Split(Split())
+
+		var msgIDs []uint16
+		for scanner.Scan() {
+			frame, body, err := Unpack(scanner.Bytes())
+			as.NoError(err)
+			as.Equal(int(frame.Len), len(body))
+			as.Equal(uint32(frame.MsgID)*10, frame.Tag)
+			as.Equal(uint64(frame.MsgID)*100, frame.UserID)
+			msgIDs = append(msgIDs, frame.MsgID)
+		}
+		as.NoError(scanner.Err())
+		as.Equal([]uint16{1, 2, 3}, msgIDs)
+	}
+}
+
+func TestSplitLenLimit(t *testing.T) {
+	var as = assert.New(t)
+
+	c := NewCodec(64)
+	data := PackWith(c, 1, 0, 0, bytes.Repeat([]byte("x"), 64))
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Split(SplitWith(c))
+	as.False(scanner.Scan())
+	as.True(errors.Is(scanner.Err(), errcode.ERR_NET_PKG_LEN_LIMIT))
+
+	_, _, err := UnpackWith(c, data[:10])
+	as.True(errors.Is(err, errcode.ERR_NET_PKG_INVALID))
+}
@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"bufio"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/protobuf/proto"
+)
+
+var defCodec iface.ICodec = NewCodec(enums.MSG_MAX_PACKET_SIZE)
+
+func Get() iface.ICodec {
+	return defCodec
+}
+
+func Set(c iface.ICodec) {
+	if c != nil {
+		defCodec = c
+	}
+}
+
+func Pack(msgID uint16, tag uint32, userID uint64, body []byte) []byte {
+	return PackWith(defCodec, msgID, tag, userID, body)
+}
+
+func PackProto(msgID uint16, tag uint32, userID uint64, msg proto.Message) ([]byte, error) {
+	return PackProtoWith(defCodec, msgID, tag, userID, msg)
+}
+
+func Unpack(data []byte) (*iface.MessageFrame, []byte, error) {
+	return UnpackWith(defCodec, data)
+}
+
+func Split() bufio.SplitFunc {
+	return SplitWith(defCodec)
+}
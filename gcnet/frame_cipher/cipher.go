@@ -0,0 +1,119 @@
+package frame_cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+type SUITE uint8
+
+const (
+	SUITE_NONE SUITE = iota
+	SUITE_AES_GCM
+	SUITE_CHACHA20_POLY1305
+)
+
+const KEY_SIZE = 32
+
+// Cipher 加解密一个连接上的帧, 包头明文并作为附加数据校验, 包体加密后 Len 增加 Overhead
+// nonce 由各方向独立递增的序号生成, 重放、丢弃或乱序的帧都无法通过校验
+// Seal 只能在写协程中调用, Open 只能在读协程中调用
+type Cipher struct {
+	codec iface.ICodec
+
+	sealer cipher.AEAD
+	opener cipher.AEAD
+
+	sendSeq uint64
+	recvSeq uint64
+}
+
+func newAEAD(suite SUITE, key []byte) (cipher.AEAD, error) {
+	switch suite {
+	case SUITE_AES_GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case SUITE_CHACHA20_POLY1305:
+		return chacha20poly1305.New(key)
+	}
+
+	return nil, errcode.ERR_NET_HANDSHAKE_FAILED
+}
+
+func NewCipher(c iface.ICodec, suite SUITE, sealKey, openKey []byte) (*Cipher, error) {
+	sealer, err := newAEAD(suite, sealKey)
+	if err != nil {
+		return nil, err
+	}
+	opener, err := newAEAD(suite, openKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{codec: c, sealer: sealer, opener: opener}, nil
+}
+
+// Overhead 每帧增加的长度
+func (c *Cipher) Overhead() int {
+	return c.sealer.Overhead()
+}
+
+func nonce(aead cipher.AEAD, seq uint64) []byte {
+	n := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(n[len(n)-8:], seq)
+
+	return n
+}
+
+// Seal 加密一个完整的帧, 返回新的帧
+func (c *Cipher) Seal(data []byte) ([]byte, error) {
+	var frame iface.MessageFrame
+	if err := c.codec.DecodeHeader(data, &frame); err != nil {
+		return nil, err
+	}
+	headerSize := c.codec.HeaderSize()
+	if len(data) < headerSize+int(frame.Len) {
+		return nil, errcode.ERR_NET_PKG_INVALID
+	}
+
+	body := data[headerSize : headerSize+int(frame.Len)]
+	out := make([]byte, headerSize, headerSize+len(body)+c.sealer.Overhead())
+	frame.Len = uint32(len(body) + c.sealer.Overhead())
+	c.codec.EncodeHeader(out, &frame)
+
+	out = c.sealer.Seal(out, nonce(c.sealer, c.sendSeq), body, out[:headerSize])
+	c.sendSeq++
+
+	return out, nil
+}
+
+// Open 原地解密一个完整的帧, 返回的帧引用 data
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	var frame iface.MessageFrame
+	if err := c.codec.DecodeHeader(data, &frame); err != nil {
+		return nil, err
+	}
+	headerSize := c.codec.HeaderSize()
+	if int(frame.Len) < c.opener.Overhead() || len(data) < headerSize+int(frame.Len) {
+		return nil, errcode.ERR_NET_PKG_INVALID
+	}
+
+	header, body := data[:headerSize], data[headerSize:headerSize+int(frame.Len)]
+	plain, err := c.opener.Open(body[:0], nonce(c.opener, c.recvSeq), body, header)
+	if err != nil {
+		return nil, errcode.ERR_NET_DECRYPT_FAILED
+	}
+	c.recvSeq++
+
+	frame.Len = uint32(len(plain))
+	c.codec.EncodeHeader(header, &frame)
+
+	return data[:headerSize+len(plain)], nil
+}
@@ -0,0 +1,49 @@
+package frame_cipher
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"net"
+	"testing"
+)
+
+func TestHandshake(t *testing.T) {
+	for _, suite := range []SUITE{SUITE_AES_GCM, SUITE_CHACHA20_POLY1305} {
+		var as = assert.New(t)
+
+		c := codec.Get()
+		sConn, cConn := net.Pipe()
+
+		done := make(chan *Cipher)
+		go func() {
+			server, err := ServerHandshake(NewStreamConn(sConn, c), c, suite)
+			as.NoError(err)
+			done <- server
+		}()
+		client, err := ClientHandshake(NewStreamConn(cConn, c), c)
+		as.NoError(err)
+		server := <-done
+
+		msg := codec.PackWith(c, 1001, 7, 9, []byte("hello"))
+		sealed, err := client.Seal(msg)
+		as.NoError(err)
+		as.Equal(len(msg)+client.Overhead(), len(sealed))
+		as.NotContains(string(sealed), "hello")
+
+		replay := append([]byte{}, sealed...)
+		opened, err := server.Open(sealed)
+		as.NoError(err)
+		as.Equal(msg, opened)
+
+		// 重放的帧序号不匹配
+		_, err = server.Open(replay)
+		as.Equal(errcode.ERR_NET_DECRYPT_FAILED, err)
+
+		// 篡改包头
+		sealed, _ = server.Seal(codec.PackWith(c, 1002, 0, 0, []byte("world")))
+		sealed[4]++
+		_, err = client.Open(sealed)
+		as.Equal(errcode.ERR_NET_DECRYPT_FAILED, err)
+	}
+}
@@ -0,0 +1,74 @@
+package frame_cipher
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"io"
+	"net"
+)
+
+// 握手帧的最大包体长度
+const handshakeMaxLen = 64
+
+// FrameReadWriter 握手时读写一个完整的帧
+type FrameReadWriter interface {
+	ReadFrame() ([]byte, error)
+	WriteFrame(data []byte) error
+}
+
+type streamConn struct {
+	conn  net.Conn
+	codec iface.ICodec
+}
+
+// NewStreamConn tcp 连接, 按包头的 Len 读取一帧
+func NewStreamConn(conn net.Conn, c iface.ICodec) FrameReadWriter {
+	return &streamConn{conn: conn, codec: c}
+}
+
+func (s *streamConn) ReadFrame() ([]byte, error) {
+	data := make([]byte, s.codec.HeaderSize(), s.codec.HeaderSize()+handshakeMaxLen)
+	if _, err := io.ReadFull(s.conn, data); err != nil {
+		return nil, err
+	}
+
+	var frame iface.MessageFrame
+	if err := s.codec.DecodeHeader(data, &frame); err != nil {
+		return nil, err
+	}
+	if frame.Len > handshakeMaxLen {
+		return nil, errcode.ERR_NET_HANDSHAKE_FAILED
+	}
+
+	data = data[:len(data)+int(frame.Len)]
+	if _, err := io.ReadFull(s.conn, data[s.codec.HeaderSize():]); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *streamConn) WriteFrame(data []byte) error {
+	_, err := s.conn.Write(data)
+	return err
+}
+
+type wsConn struct {
+	conn        *websocket.Conn
+	messageType int
+}
+
+// NewWsConn websocket 连接, 每条消息为一帧
+func NewWsConn(conn *websocket.Conn, messageType int) FrameReadWriter {
+	return &wsConn{conn: conn, messageType: messageType}
+}
+
+func (w *wsConn) ReadFrame() ([]byte, error) {
+	_, data, err := w.conn.ReadMessage()
+	return data, err
+}
+
+func (w *wsConn) WriteFrame(data []byte) error {
+	return w.conn.WriteMessage(w.messageType, data)
+}
@@ -0,0 +1,148 @@
+package frame_cipher
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+const hkdfInfo = "gc frame_cipher v1"
+
+// Handshake X25519 临时密钥交换, 连接建立后客户端先发送 Hello, 服务器回复 Accept 的结果
+// 只防止抓包和篡改, 不验证服务器身份, 需要防中间人时配合 TLS 使用
+//
+//	client -> server: MSG_ID_HANDSHAKE | client public key(32)
+//	server -> client: MSG_ID_HANDSHAKE | suite(1) | server public key(32)
+type Handshake struct {
+	codec iface.ICodec
+	priv  *ecdh.PrivateKey
+}
+
+func NewHandshake(c iface.ICodec) (*Handshake, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handshake{codec: c, priv: priv}, nil
+}
+
+// Hello 客户端发送的握手帧
+func (h *Handshake) Hello() []byte {
+	return codec.PackWith(h.codec, enums.MSG_ID_HANDSHAKE, 0, 0, h.priv.PublicKey().Bytes())
+}
+
+// Accept 服务器处理客户端的握手帧, 返回需要回复的帧
+func (h *Handshake) Accept(hello []byte, suite SUITE) ([]byte, *Cipher, error) {
+	body, err := h.unpack(hello)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c2s, s2c, err := h.derive(body, body, h.priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := NewCipher(h.codec, suite, s2c, c2s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reply := append([]byte{byte(suite)}, h.priv.PublicKey().Bytes()...)
+
+	return codec.PackWith(h.codec, enums.MSG_ID_HANDSHAKE, 0, 0, reply), c, nil
+}
+
+// Finish 客户端处理服务器的回复
+func (h *Handshake) Finish(reply []byte) (*Cipher, error) {
+	body, err := h.unpack(reply)
+	if err != nil || len(body) < 1 {
+		return nil, errcode.ERR_NET_HANDSHAKE_FAILED
+	}
+
+	suite, peer := SUITE(body[0]), body[1:]
+	c2s, s2c, err := h.derive(peer, h.priv.PublicKey().Bytes(), peer)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCipher(h.codec, suite, c2s, s2c)
+}
+
+func (h *Handshake) unpack(data []byte) ([]byte, error) {
+	frame, body, err := codec.UnpackWith(h.codec, data)
+	if err != nil {
+		return nil, err
+	}
+	if frame.MsgID != enums.MSG_ID_HANDSHAKE {
+		return nil, errcode.ERR_NET_HANDSHAKE_FAILED
+	}
+
+	return body, nil
+}
+
+// derive 由共享密钥派生两个方向的密钥
+func (h *Handshake) derive(peer, clientPub, serverPub []byte) (c2s, s2c []byte, err error) {
+	pub, err := ecdh.X25519().NewPublicKey(peer)
+	if err != nil {
+		return nil, nil, errcode.ERR_NET_HANDSHAKE_FAILED
+	}
+	secret, err := h.priv.ECDH(pub)
+	if err != nil {
+		return nil, nil, errcode.ERR_NET_HANDSHAKE_FAILED
+	}
+
+	salt := append(append([]byte{}, clientPub...), serverPub...)
+	key := make([]byte, 2*KEY_SIZE)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(hkdfInfo)), key); err != nil {
+		return nil, nil, err
+	}
+
+	return key[:KEY_SIZE], key[KEY_SIZE:], nil
+}
+
+// ServerHandshake 服务器在连接上完成握手
+func ServerHandshake(rw FrameReadWriter, c iface.ICodec, suite SUITE) (*Cipher, error) {
+	h, err := NewHandshake(c)
+	if err != nil {
+		return nil, err
+	}
+
+	hello, err := rw.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	reply, cph, err := h.Accept(hello, suite)
+	if err != nil {
+		return nil, err
+	}
+	if err = rw.WriteFrame(reply); err != nil {
+		return nil, err
+	}
+
+	return cph, nil
+}
+
+// ClientHandshake 客户端在连接上完成握手
+func ClientHandshake(rw FrameReadWriter, c iface.ICodec) (*Cipher, error) {
+	h, err := NewHandshake(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.WriteFrame(h.Hello()); err != nil {
+		return nil, err
+	}
+	reply, err := rw.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Finish(reply)
+}
@@ -0,0 +1,283 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"github.com/v587-zyf/gc/worker_pool"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io"
+	"kernel/tools"
+	"sync"
+)
+
+// gate 一条来自网关的转发流
+type gate struct {
+	sendCh chan []byte
+}
+
+func (g *gate) send(data []byte) error {
+	select {
+	case g.sendCh <- data:
+		return nil
+	default:
+		return errcode.ERR_NET_RELAY_UNAVAILABLE
+	}
+}
+
+// Backend 后端接收网关转发的消息, 每个用户对应一个 UserSession 交给 WithMethod 处理
+type Backend struct {
+	options *GatewayOption
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	gates map[*gate]struct{}
+	users map[uint64]*UserSession
+
+	pool *worker_pool.WorkerPool
+}
+
+func NewBackend() *Backend {
+	b := &Backend{
+		options: NewGatewayOption(),
+		gates:   make(map[*gate]struct{}),
+		users:   make(map[uint64]*UserSession),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	return b
+}
+
+func (b *Backend) Init(ctx context.Context, opts ...Option) (err error) {
+	b.cancel()
+	b.ctx, b.cancel = context.WithCancel(ctx)
+
+	for _, opt := range opts {
+		opt(b.options)
+	}
+	if b.options.assign == nil {
+		b.pool = worker_pool.NewWorkerPool()
+		if err = b.pool.Init(b.ctx); err != nil {
+			return
+		}
+		b.pool.Start()
+		b.options.assign = b.pool.AssignKeyedFunc
+	}
+
+	return nil
+}
+
+// Register 注册到 grpc 服务器, 如 b.Register(grpc_server.GetServer())
+func (b *Backend) Register(s *grpc.Server) {
+	s.RegisterService(&relayDesc, b)
+}
+
+func (b *Backend) relay(stream grpc.ServerStream) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	gt := &gate{sendCh: make(chan []byte, b.options.queueSize)}
+	b.mu.Lock()
+	b.gates[gt] = struct{}{}
+	b.mu.Unlock()
+	defer b.gateDel(gt)
+
+	// handler 返回后不能再调用 SendMsg, 需等待写协程退出
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go tools.GoSafe("gateway backend relay write", func() {
+		defer wg.Done()
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.ctx.Done():
+				return
+			case data := <-gt.sendCh:
+				if err := stream.SendMsg(&wrapperspb.BytesValue{Value: data}); err != nil {
+					return
+				}
+			}
+		}
+	})
+	defer wg.Wait()
+	defer cancel()
+
+	for {
+		msg := new(wrapperspb.BytesValue)
+		if err := stream.RecvMsg(msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		b.recv(gt, msg.Value)
+	}
+}
+
+func (b *Backend) recv(gt *gate, data []byte) {
+	var frame iface.MessageFrame
+	if err := b.options.codec.DecodeHeader(data, &frame); err != nil {
+		log.Warn("gateway backend decode header err", zap.Error(err))
+		return
+	}
+	if frame.UserID == 0 {
+		return
+	}
+
+	if frame.MsgID == enums.MSG_ID_GATE_OFFLINE {
+		b.offline(frame.UserID, gt)
+		return
+	}
+
+	ss := b.session(frame.UserID, gt)
+	if b.options.method != nil {
+		b.exec(frame.UserID, func() {
+			b.options.method.Recv(ss, data)
+		})
+	}
+}
+
+// exec 按 userID 在协程池中串行执行, 同一用户保持顺序, 慢的用户不阻塞同一网关上的其他用户
+func (b *Backend) exec(userID uint64, fn func()) {
+	if b.options.assign == nil {
+		fn()
+		return
+	}
+	if err := b.options.assign(userID, fn); err != nil {
+		log.Warn("gateway backend assign err", zap.Uint64("userID", userID), zap.Error(err))
+	}
+}
+
+// session 返回用户的会话, 不存在时创建, 用户换了网关时改为从新网关发送
+func (b *Backend) session(userID uint64, gt *gate) *UserSession {
+	b.mu.Lock()
+	ss, ok := b.users[userID]
+	if ok {
+		ss.gate.Store(gt)
+		b.mu.Unlock()
+		return ss
+	}
+	ss = newUserSession(b, userID, gt)
+	b.users[userID] = ss
+	b.mu.Unlock()
+
+	if b.options.method != nil {
+		b.exec(userID, func() {
+			b.options.method.Start(ss)
+		})
+	}
+
+	return ss
+}
+
+// offline 网关通知用户断开, 用户已换到其他网关时忽略
+func (b *Backend) offline(userID uint64, gt *gate) {
+	b.mu.Lock()
+	ss, ok := b.users[userID]
+	if !ok || ss.gate.Load() != gt {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.users, userID)
+	b.mu.Unlock()
+
+	b.stop(ss)
+}
+
+// gateDel 网关断开时其上的用户全部下线
+func (b *Backend) gateDel(gt *gate) {
+	b.mu.Lock()
+	delete(b.gates, gt)
+	var offline []*UserSession
+	for userID, ss := range b.users {
+		if ss.gate.Load() == gt {
+			delete(b.users, userID)
+			offline = append(offline, ss)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, ss := range offline {
+		b.stop(ss)
+	}
+}
+
+// stop 排在该用户已收到的消息之后执行
+func (b *Backend) stop(ss *UserSession) {
+	b.exec(ss.id, func() {
+		ss.cancel()
+		if b.options.method != nil {
+			b.options.method.Stop(ss)
+		}
+	})
+}
+
+// GetSession 返回经网关在线的用户
+func (b *Backend) GetSession(userID uint64) (iface.ISession, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ss, ok := b.users[userID]; ok {
+		return ss, true
+	}
+	return nil, false
+}
+
+// Send2User 经网关发送消息给用户的所有连接, 用户未经本服务时发给所有网关, 由在线的网关转发
+func (b *Backend) Send2User(userID uint64, msgID uint16, msg proto.Message) error {
+	data, err := codec.PackProtoWith(b.options.codec, msgID, 0, userID, msg)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	ss, ok := b.users[userID]
+	b.mu.Unlock()
+	if ok {
+		return ss.send(data)
+	}
+
+	b.sendAll(data)
+	return nil
+}
+
+// Broadcast 经所有网关广播给已登录的连接
+func (b *Backend) Broadcast(msgID uint16, msg proto.Message) error {
+	data, err := codec.PackProtoWith(b.options.codec, msgID, 0, 0, msg)
+	if err != nil {
+		return err
+	}
+
+	b.sendAll(data)
+	return nil
+}
+
+func (b *Backend) sendAll(data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for gt := range b.gates {
+		if err := gt.send(data); err != nil {
+			log.Warn("gateway backend send err", zap.Error(err))
+		}
+	}
+}
+
+func (b *Backend) Close() {
+	b.cancel()
+	if b.pool != nil {
+		b.pool.Stop()
+	}
+}
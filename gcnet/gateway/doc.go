@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	defGateway *Gateway
+	defBackend *Backend
+)
+
+// Init 网关进程调用, 如 Init(ctx, WithRoute(1000, 1999, enums.SERVER_GAME), WithBackend(enums.SERVER_GAME, grpc_client.GetClient()))
+func Init(ctx context.Context, opts ...Option) (err error) {
+	defGateway = NewGateway()
+	if err = defGateway.Init(ctx, opts...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Get() *Gateway {
+	return defGateway
+}
+
+func Close() {
+	defGateway.Close()
+}
+
+// InitBackend 后端进程调用, 如 InitBackend(ctx, WithMethod(handler.Get())) 后 RegisterBackend(grpc_server.GetServer())
+func InitBackend(ctx context.Context, opts ...Option) (err error) {
+	defBackend = NewBackend()
+	if err = defBackend.Init(ctx, opts...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func GetBackend() *Backend {
+	return defBackend
+}
+
+func RegisterBackend(s *grpc.Server) {
+	defBackend.Register(s)
+}
+
+func GetSession(userID uint64) (iface.ISession, bool) {
+	return defBackend.GetSession(userID)
+}
+
+func Send2User(userID uint64, msgID uint16, msg proto.Message) error {
+	return defBackend.Send2User(userID, msgID, msg)
+}
+
+func Broadcast(msgID uint16, msg proto.Message) error {
+	return defBackend.Broadcast(msgID, msg)
+}
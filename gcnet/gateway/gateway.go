@@ -0,0 +1,315 @@
+package gateway
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"kernel/tools"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type backend struct {
+	serverType int32
+	conn       backendConn
+	sendCh     chan []byte
+	ready      atomic.Bool
+}
+
+// Gateway 按 msgID 把客户端消息经 grpc 双向流转发到后端, 后端发回的消息按 UserID 交给对应会话
+// 实现 iface.ISessionMethod, 可直接用于 tcp_server.WithMethod 和 ws_server.WithMethod
+type Gateway struct {
+	options *GatewayOption
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	backends map[int32][]*backend // serverType:backends
+
+	stopMu  sync.Mutex
+	stopped map[uint64]map[iface.ISession]struct{} // userID:已断开但可能仍在在线列表中的会话
+}
+
+func NewGateway() *Gateway {
+	return &Gateway{
+		options:  NewGatewayOption(),
+		backends: make(map[int32][]*backend),
+		stopped:  make(map[uint64]map[iface.ISession]struct{}),
+	}
+}
+
+func (g *Gateway) Init(ctx context.Context, opts ...Option) (err error) {
+	g.ctx, g.cancel = context.WithCancel(ctx)
+
+	for _, opt := range opts {
+		opt(g.options)
+	}
+
+	for _, r := range g.options.routes {
+		if r.min > r.max {
+			return errcode.ERR_PARAM
+		}
+	}
+	for _, c := range g.options.backends {
+		g.backends[c.serverType] = append(g.backends[c.serverType], &backend{
+			serverType: c.serverType,
+			conn:       c,
+			sendCh:     make(chan []byte, g.options.queueSize),
+		})
+	}
+
+	for _, bs := range g.backends {
+		for _, b := range bs {
+			g.wg.Add(1)
+			go tools.GoSafe("gateway backend loop", func() {
+				defer g.wg.Done()
+				g.run(b)
+			})
+		}
+	}
+
+	return nil
+}
+
+// Close 断开所有后端
+func (g *Gateway) Close() {
+	g.cancel()
+	g.wg.Wait()
+}
+
+func (g *Gateway) Name() string {
+	return "gateway"
+}
+
+func (g *Gateway) Start(ss iface.ISession) {}
+
+// Recv 路由表内的消息转发到后端, 其余交给 WithLocalFn
+func (g *Gateway) Recv(ss iface.ISession, data any) {
+	buf, ok := data.([]byte)
+	if !ok {
+		log.Warn("gateway recv data type err", zap.Uint64("userID", ss.GetID()))
+		return
+	}
+
+	var frame iface.MessageFrame
+	if err := g.options.codec.DecodeHeader(buf, &frame); err != nil {
+		log.Warn("gateway decode header err", zap.Uint64("userID", ss.GetID()), zap.Error(err))
+		return
+	}
+
+	serverType, ok := g.route(frame.MsgID)
+	if !ok {
+		if g.options.localFn != nil {
+			g.options.localFn(ss, data)
+		}
+		return
+	}
+
+	if err := g.Forward(serverType, ss, buf); err != nil {
+		log.Warn("gateway forward err", zap.Uint64("userID", ss.GetID()), zap.Uint16("msgID", frame.MsgID),
+			zap.Int32("serverType", serverType), zap.Error(err))
+		if g.options.errFn != nil {
+			g.options.errFn(ss, &frame, err)
+		}
+	}
+}
+
+// Stop 用户的最后一个会话断开时通知所有后端, 多端登录时其他设备仍在线则不通知
+func (g *Gateway) Stop(ss iface.ISession) {
+	userID := ss.GetID()
+	if userID == 0 {
+		return
+	}
+	if !g.lastStop(userID, ss) {
+		return
+	}
+
+	data := codec.PackWith(g.options.codec, enums.MSG_ID_GATE_OFFLINE, 0, userID, nil)
+	for _, bs := range g.backends {
+		for _, b := range bs {
+			if b.ready.Load() {
+				b.send(data)
+			}
+		}
+	}
+}
+
+// lastStop 记录 ss 已断开, 返回用户是否已没有其他未断开的会话
+// 会话断开后才从在线列表移除, 多个设备同时断开时需排除已断开的会话
+// 只记录仍在在线列表中的会话, 被踢或被顶替的会话已移出在线列表, 不会残留
+func (g *Gateway) lastStop(userID uint64, ss iface.ISession) bool {
+	online := g.options.sessionMgr.OnlineGetAll(userID)
+
+	g.stopMu.Lock()
+	defer g.stopMu.Unlock()
+
+	last := true
+	stopped := make(map[iface.ISession]struct{}, len(online))
+	for _, v := range online {
+		if _, ok := g.stopped[userID][v]; ok || v == ss {
+			stopped[v] = struct{}{}
+		} else {
+			last = false
+		}
+	}
+	if last || len(stopped) == 0 {
+		delete(g.stopped, userID)
+	} else {
+		g.stopped[userID] = stopped
+	}
+
+	return last
+}
+
+func (g *Gateway) route(msgID uint16) (int32, bool) {
+	if msgID == enums.MSG_ID_GATE_OFFLINE || msgID == enums.MSG_ID_GATE_KICK {
+		return 0, false
+	}
+
+	for _, r := range g.options.routes {
+		if msgID >= r.min && msgID <= r.max {
+			return r.serverType, true
+		}
+	}
+
+	return 0, false
+}
+
+// Forward 把一个完整的帧转发到 serverType 的后端, 包头的 UserID 改为会话的用户 ID
+func (g *Gateway) Forward(serverType int32, ss iface.ISession, data []byte) error {
+	userID := ss.GetID()
+	if userID == 0 {
+		return errcode.ERR_NET_NOT_LOGIN
+	}
+
+	bs := g.backends[serverType]
+	if len(bs) == 0 {
+		return errcode.ERR_NET_RELAY_UNAVAILABLE
+	}
+	b := bs[userID%uint64(len(bs))]
+	if !b.ready.Load() {
+		return errcode.ERR_NET_RELAY_UNAVAILABLE
+	}
+
+	var frame iface.MessageFrame
+	if err := g.options.codec.DecodeHeader(data, &frame); err != nil {
+		return err
+	}
+	headerSize := g.options.codec.HeaderSize()
+	if len(data) < headerSize+int(frame.Len) {
+		return errcode.ERR_NET_PKG_INVALID
+	}
+
+	// data 可能来自 buffer_pool, 需要复制
+	buf := make([]byte, headerSize+int(frame.Len))
+	copy(buf, data)
+	frame.UserID = userID
+	g.options.codec.EncodeHeader(buf, &frame)
+
+	return b.send(buf)
+}
+
+func (b *backend) send(data []byte) error {
+	select {
+	case b.sendCh <- data:
+		return nil
+	default:
+		return errcode.ERR_NET_RELAY_UNAVAILABLE
+	}
+}
+
+// run 维持与后端的转发流, 断开后按指数退避重连
+func (g *Gateway) run(b *backend) {
+	interval := enums.RECONNECT_MIN_INTERVAL
+	for {
+		connected, err := g.serve(b)
+		if g.ctx.Err() != nil {
+			return
+		}
+		log.Warn("gateway backend disconnected", zap.Int32("serverType", b.serverType), zap.Error(err))
+
+		if connected {
+			interval = enums.RECONNECT_MIN_INTERVAL
+		}
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, enums.RECONNECT_MAX_INTERVAL)
+	}
+}
+
+func (g *Gateway) serve(b *backend) (connected bool, err error) {
+	ctx, cancel := context.WithCancel(g.ctx)
+	defer cancel()
+
+	stream, err := b.conn.cc.NewStream(ctx, &relayDesc.Streams[0], relayMethod)
+	if err != nil {
+		return false, err
+	}
+
+	b.ready.Store(true)
+	defer b.ready.Store(false)
+	log.Info("gateway backend connected", zap.Int32("serverType", b.serverType))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go tools.GoSafe("gateway backend write", func() {
+		defer wg.Done()
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data := <-b.sendCh:
+				if err := stream.SendMsg(&wrapperspb.BytesValue{Value: data}); err != nil {
+					return
+				}
+			}
+		}
+	})
+	defer wg.Wait()
+
+	for {
+		msg := new(wrapperspb.BytesValue)
+		if err = stream.RecvMsg(msg); err != nil {
+			return true, err
+		}
+		g.deliver(msg.Value)
+	}
+}
+
+// deliver 把后端发回的帧交给对应用户的所有会话, UserID 为 0 时广播给所有已登录会话
+func (g *Gateway) deliver(data []byte) {
+	var frame iface.MessageFrame
+	if err := g.options.codec.DecodeHeader(data, &frame); err != nil {
+		log.Warn("gateway backend frame err", zap.Error(err))
+		return
+	}
+
+	mgr := g.options.sessionMgr
+	switch {
+	case frame.MsgID == enums.MSG_ID_GATE_KICK:
+		if frame.UserID != 0 {
+			mgr.Kick(frame.UserID, errcode.ERR_NET_KICKED)
+		}
+	case frame.UserID == 0:
+		mgr.BroadcastOnline(data)
+	default:
+		fn := func(args ...any) ([]byte, error) {
+			return data, nil
+		}
+		for _, ss := range mgr.OnlineGetAll(frame.UserID) {
+			ss.SendMsg(fn)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package gateway
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net"
+	"testing"
+	"time"
+)
+
+type testSession struct {
+	iface.ISession
+	id     uint64
+	device string
+	sent   chan []byte
+	closed chan struct{}
+}
+
+func newTestSession(id uint64) *testSession {
+	return &testSession{id: id, sent: make(chan []byte, 8), closed: make(chan struct{})}
+}
+
+func (s *testSession) GetID() uint64 { return s.id }
+
+func (s *testSession) Get(key string) (any, bool) {
+	if key == session_mgr.DEVICE_KEY {
+		return s.device, true
+	}
+	return nil, false
+}
+
+func (s *testSession) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	data, err := fn(args...)
+	if err != nil {
+		return err
+	}
+	s.sent <- data
+	return nil
+}
+
+func (s *testSession) Close() error {
+	close(s.closed)
+	return nil
+}
+
+// echoMethod 原样回复, msgID 为 2 时关闭会话
+type echoMethod struct {
+	stopped chan uint64
+}
+
+func (m *echoMethod) Name() string { return "echo" }
+
+func (m *echoMethod) Start(ss iface.ISession) {}
+
+func (m *echoMethod) Recv(ss iface.ISession, data any) {
+	frame, _, _ := codec.Unpack(data.([]byte))
+	if frame.MsgID == 2 {
+		ss.Close()
+		return
+	}
+	ss.SendMsg(func(args ...any) ([]byte, error) {
+		return data.([]byte), nil
+	})
+}
+
+func (m *echoMethod) Stop(ss iface.ISession) {
+	m.stopped <- ss.GetID()
+}
+
+func recv(t *testing.T, ss *testSession) []byte {
+	select {
+	case data := <-ss.sent:
+		return data
+	case <-time.After(time.Second):
+		t.Fatal("recv timeout")
+		return nil
+	}
+}
+
+func TestGateway(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	lis := bufconn.Listen(1 << 20)
+	method := &echoMethod{stopped: make(chan uint64, 1)}
+	b := NewBackend()
+	as.NoError(b.Init(context.Background(), WithMethod(method)))
+	s := grpc.NewServer()
+	b.Register(s)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	cc, err := grpc.NewClient("passthrough:bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	as.NoError(err)
+	defer cc.Close()
+
+	mgr := session_mgr.NewSessionMgr()
+	mgr.Init(context.Background(), session_mgr.WithLoginPolicy(session_mgr.LOGIN_POLICY_MULTI_DEVICE))
+	var local []uint16
+	g := NewGateway()
+	as.NoError(g.Init(context.Background(), WithSessionMgr(mgr),
+		WithRoute(1, 99, enums.SERVER_GAME), WithBackend(enums.SERVER_GAME, cc),
+		WithLocalFn(func(ss iface.ISession, data any) {
+			frame, _, _ := codec.Unpack(data.([]byte))
+			local = append(local, frame.MsgID)
+		})))
+	defer g.Close()
+
+	ss, other, guest := newTestSession(7), newTestSession(8), newTestSession(0)
+	for _, v := range []*testSession{ss, other, guest} {
+		mgr.AllAdd(v)
+	}
+	mgr.OnlineAdd(ss.id, ss)
+	mgr.OnlineAdd(other.id, other)
+
+	as.Eventually(func() bool {
+		return g.Forward(enums.SERVER_GAME, ss, codec.Pack(0, 0, 0, nil)) == nil
+	}, time.Second, 10*time.Millisecond)
+	<-ss.sent
+
+	// 客户端填写的 UserID 被网关改为会话的用户 ID
+	g.Recv(ss, codec.Pack(1, 3, 99, []byte("hi")))
+	frame, body, err := codec.Unpack(recv(t, ss))
+	as.NoError(err)
+	as.Equal(uint16(1), frame.MsgID)
+	as.Equal(uint32(3), frame.Tag)
+	as.Equal(uint64(7), frame.UserID)
+	as.Equal([]byte("hi"), body)
+
+	// 未命中路由的交给本地处理, 未登录的不转发
+	g.Recv(ss, codec.Pack(100, 0, 0, nil))
+	as.Equal([]uint16{100}, local)
+	as.Error(g.Forward(enums.SERVER_GAME, guest, codec.Pack(1, 0, 0, nil)))
+
+	// 后端推送和广播
+	as.NoError(b.Send2User(8, 5, wrapperspb.String("one")))
+	frame, _, _ = codec.Unpack(recv(t, other))
+	as.Equal(uint16(5), frame.MsgID)
+	as.NoError(b.Broadcast(6, wrapperspb.String("all")))
+	for _, v := range []*testSession{ss, other} {
+		frame, _, _ = codec.Unpack(recv(t, v))
+		as.Equal(uint16(6), frame.MsgID)
+	}
+	as.Empty(guest.sent)
+
+	// 后端关闭会话时网关踢掉用户
+	g.Recv(other, codec.Pack(2, 0, 0, nil))
+	select {
+	case <-other.closed:
+	case <-time.After(time.Second):
+		t.Fatal("kick timeout")
+	}
+
+	// 多端登录时其他设备仍在线, 不通知后端
+	pc := newTestSession(7)
+	pc.device = "pc"
+	mgr.OnlineAdd(pc.id, pc)
+	g.Stop(pc)
+	select {
+	case <-method.stopped:
+		t.Fatal("offline with other device online")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 网关断开用户最后一个会话时通知后端, 已断开的设备即使还在在线列表中也不影响
+	g.Stop(ss)
+	select {
+	case userID := <-method.stopped:
+		as.Equal(uint64(7), userID)
+	case <-time.After(time.Second):
+		t.Fatal("offline timeout")
+	}
+	_, ok := b.GetSession(7)
+	as.False(ok)
+
+	// 被顶替的旧会话已移出在线列表, 断开时不留下记录
+	old, cur := newTestSession(9), newTestSession(9)
+	mgr.OnlineAdd(old.id, old)
+	mgr.OnlineAdd(cur.id, cur)
+	g.Stop(old)
+	g.stopMu.Lock()
+	as.Empty(g.stopped)
+	g.stopMu.Unlock()
+}
+
+// slowMethod userID 为 1 的消息阻塞, 记录其他用户收到的消息
+type slowMethod struct {
+	block chan struct{}
+	recv  chan uint64
+}
+
+func (m *slowMethod) Name() string { return "slow" }
+
+func (m *slowMethod) Start(ss iface.ISession) {}
+
+func (m *slowMethod) Recv(ss iface.ISession, data any) {
+	if ss.GetID() == 1 {
+		<-m.block
+	}
+	m.recv <- ss.GetID()
+}
+
+func (m *slowMethod) Stop(ss iface.ISession) {}
+
+func TestBackendNoHeadOfLineBlocking(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	method := &slowMethod{block: make(chan struct{}), recv: make(chan uint64, 8)}
+	b := NewBackend()
+	as.NoError(b.Init(context.Background(), WithMethod(method)))
+	defer b.Close()
+
+	gt := &gate{sendCh: make(chan []byte, 8)}
+	b.recv(gt, codec.Pack(1, 0, 1, nil))
+	b.recv(gt, codec.Pack(1, 0, 1, nil))
+	b.recv(gt, codec.Pack(1, 0, 2, nil))
+
+	// 用户 1 阻塞时用户 2 的消息照常处理
+	select {
+	case userID := <-method.recv:
+		as.Equal(uint64(2), userID)
+	case <-time.After(time.Second):
+		t.Fatal("blocked by another user")
+	}
+
+	close(method.block)
+	for i := 0; i < 2; i++ {
+		select {
+		case userID := <-method.recv:
+			as.Equal(uint64(1), userID)
+		case <-time.After(time.Second):
+			t.Fatal("recv timeout")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/grpc"
+)
+
+// ErrFn 网关转发失败时调用, 可用于回复错误消息, 如 handler.Get().ReplyErr(ss, frame.Tag, err)
+type ErrFn func(ss iface.ISession, frame *iface.MessageFrame, err error)
+
+// KeyedAssignFn 按 key 串行执行任务, 如 worker_pool.AssignKeyedFunc
+type KeyedAssignFn func(key any, fn func()) error
+
+type route struct {
+	min, max   uint16
+	serverType int32
+}
+
+type backendConn struct {
+	serverType int32
+	cc         grpc.ClientConnInterface
+}
+
+// GatewayOption Gateway 和 Backend 共用, 各自只读取相关的字段
+type GatewayOption struct {
+	codec     iface.ICodec
+	queueSize int
+
+	// 网关
+	routes     []route
+	backends   []backendConn
+	localFn    iface.SessionRecv
+	errFn      ErrFn
+	sessionMgr *session_mgr.SessionMgr
+
+	// 后端
+	method iface.ISessionMethod
+	assign KeyedAssignFn
+}
+
+type Option func(opts *GatewayOption)
+
+func NewGatewayOption() *GatewayOption {
+	o := &GatewayOption{
+		codec:      codec.Get(),
+		queueSize:  enums.GATE_QUEUE_SIZE,
+		sessionMgr: session_mgr.GetSessionMgr(),
+	}
+
+	return o
+}
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *GatewayOption) {
+		if c != nil {
+			opts.codec = c
+		}
+	}
+}
+
+func WithQueueSize(size int) Option {
+	return func(opts *GatewayOption) {
+		if size > 0 {
+			opts.queueSize = size
+		}
+	}
+}
+
+// WithRoute 网关把 [min, max] 范围内的消息转发到 serverType 的后端, 如 WithRoute(1000, 1999, enums.SERVER_GAME)
+func WithRoute(min, max uint16, serverType int32) Option {
+	return func(opts *GatewayOption) {
+		opts.routes = append(opts.routes, route{min: min, max: max, serverType: serverType})
+	}
+}
+
+// WithBackend 网关连接的后端, 同一类型可多次添加, 按 userID 固定转发到其中一个
+func WithBackend(serverType int32, cc grpc.ClientConnInterface) Option {
+	return func(opts *GatewayOption) {
+		opts.backends = append(opts.backends, backendConn{serverType: serverType, cc: cc})
+	}
+}
+
+// WithLocalFn 网关未命中路由的消息交给 fn 在本地处理, 如 handler.Get().Dispatch
+func WithLocalFn(fn iface.SessionRecv) Option {
+	return func(opts *GatewayOption) {
+		opts.localFn = fn
+	}
+}
+
+func WithErrFn(fn ErrFn) Option {
+	return func(opts *GatewayOption) {
+		opts.errFn = fn
+	}
+}
+
+// WithSessionMgr 网关查找用户会话的管理器, 默认使用 session_mgr 的默认实例
+func WithSessionMgr(mgr *session_mgr.SessionMgr) Option {
+	return func(opts *GatewayOption) {
+		if mgr != nil {
+			opts.sessionMgr = mgr
+		}
+	}
+}
+
+// WithMethod 后端处理用户消息的方法, 如 handler.Get()
+func WithMethod(m iface.ISessionMethod) Option {
+	return func(opts *GatewayOption) {
+		opts.method = m
+	}
+}
+
+// WithAssign 后端执行用户消息的协程池, 按 userID 串行, 默认使用 Backend 自己的协程池
+func WithAssign(fn KeyedAssignFn) Option {
+	return func(opts *GatewayOption) {
+		opts.assign = fn
+	}
+}
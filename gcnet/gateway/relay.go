@@ -0,0 +1,30 @@
+package gateway
+
+import (
+	"google.golang.org/grpc"
+)
+
+// 网关和后端之间只有一个双向流, 每条消息是一个完整的帧(包头+包体), 用 wrapperspb.BytesValue 承载
+// 网关发往后端的帧 UserID 为会话的用户 ID, 后端发回的帧 UserID 为目标用户, 0 表示广播
+
+const relayMethod = "/gc.gateway.Relay/Stream"
+
+type relayServer interface {
+	relay(stream grpc.ServerStream) error
+}
+
+var relayDesc = grpc.ServiceDesc{
+	ServiceName: "gc.gateway.Relay",
+	HandlerType: (*relayServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Stream",
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				return srv.(relayServer).relay(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "gateway/relay",
+}
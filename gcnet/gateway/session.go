@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UserSession 后端中经网关连接的用户, 实现 iface.ISession, 可直接复用 handler
+// SendMsg 经网关发给该用户的所有连接, Close 通知网关踢掉该用户
+type UserSession struct {
+	backend *Backend
+	gate    atomic.Pointer[gate]
+
+	id   uint64
+	data sync.Map
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newUserSession(b *Backend, userID uint64, gt *gate) *UserSession {
+	s := &UserSession{
+		backend: b,
+		id:      userID,
+	}
+	s.gate.Store(gt)
+	s.ctx, s.cancel = context.WithCancel(b.ctx)
+
+	return s
+}
+
+func (s *UserSession) Set(key string, value any) {
+	s.data.Store(key, value)
+}
+
+func (s *UserSession) Get(key string) (any, bool) {
+	return s.data.Load(key)
+}
+
+func (s *UserSession) Remove(key string) {
+	s.data.Delete(key)
+}
+
+func (s *UserSession) GetID() uint64 {
+	return s.id
+}
+
+// SetID 用户 ID 由网关决定, 不能修改
+func (s *UserSession) SetID(id uint64) {}
+
+func (s *UserSession) Start() {}
+
+func (s *UserSession) Close() error {
+	return s.send(codec.PackWith(s.backend.options.codec, enums.MSG_ID_GATE_KICK, 0, s.id, nil))
+}
+
+// GetCtx 用户下线时取消
+func (s *UserSession) GetCtx() context.Context {
+	return s.ctx
+}
+
+func (s *UserSession) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	data, err := fn(args...)
+	if err != nil {
+		return err
+	}
+
+	return s.send(data)
+}
+
+// send 包头的 UserID 不是该用户时复制后改写, 避免修改调用方共享的数据
+func (s *UserSession) send(data []byte) error {
+	c := s.backend.options.codec
+
+	var frame iface.MessageFrame
+	if err := c.DecodeHeader(data, &frame); err != nil {
+		return err
+	}
+	if frame.UserID != s.id {
+		buf := make([]byte, len(data))
+		copy(buf, data)
+		frame.UserID = s.id
+		c.EncodeHeader(buf, &frame)
+		data = buf
+	}
+
+	return s.gate.Load().send(data)
+}
+
+func (s *UserSession) DoSomething(fn func(args ...any) bool) bool {
+	return fn()
+}
+
+func (s *UserSession) CheckSomething(fn func(args ...any) bool) bool {
+	return fn()
+}
+
+// IsHeartbeatTimeout 心跳由网关检查
+func (s *UserSession) IsHeartbeatTimeout(now time.Time) bool {
+	return false
+}
@@ -3,6 +3,8 @@ package grpc_client
 import (
 	"context"
 	"fmt"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/registry"
 	"github.com/v587-zyf/gc/log"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -45,6 +47,20 @@ func (s *GrpcClient) Init(ctx context.Context, option ...any) (err error) {
 		grpc.WithKeepaliveParams(keepAliveParams),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	}
+	if s.options.registry != nil {
+		linkAddr = fmt.Sprintf("%s:///%d", enums.REGISTRY_SCHEME, s.options.serverType)
+		opts = append(opts,
+			grpc.WithResolvers(registry.NewResolverBuilder(s.options.registry)),
+			grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{"%s":{}}]}`, s.options.balancer)),
+		)
+	}
+	if len(s.options.unaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(s.options.unaryInterceptors...))
+	}
+	if len(s.options.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(s.options.streamInterceptors...))
+	}
+	opts = append(opts, s.options.dialOptions...)
 
 	s.client, err = grpc.NewClient(linkAddr, opts...)
 	if err != nil {
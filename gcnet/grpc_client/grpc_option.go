@@ -1,13 +1,29 @@
 package grpc_client
 
+import (
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/grpc"
+)
+
 type GrpcOption struct {
 	listenAddr string
+
+	registry   iface.IRegistry
+	serverType int32
+	balancer   string
+
+	dialOptions        []grpc.DialOption
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
 }
 
 type Option func(opts *GrpcOption)
 
 func NewGrpcOption() *GrpcOption {
-	o := &GrpcOption{}
+	o := &GrpcOption{
+		balancer: enums.BALANCER_ROUND_ROBIN,
+	}
 
 	return o
 }
@@ -17,3 +33,40 @@ func WithListenAddr(addr string) Option {
 		opts.listenAddr = addr
 	}
 }
+
+// WithRegistry 通过注册中心发现 serverType 的所有实例, 设置后忽略 WithListenAddr
+func WithRegistry(reg iface.IRegistry, serverType int32) Option {
+	return func(opts *GrpcOption) {
+		opts.registry = reg
+		opts.serverType = serverType
+	}
+}
+
+// WithBalancer 多个实例间的负载均衡, 如 enums.BALANCER_CONSISTENT_HASH 配合 registry.NewHashCtx 按 userID 选择实例
+func WithBalancer(name string) Option {
+	return func(opts *GrpcOption) {
+		if name != "" {
+			opts.balancer = name
+		}
+	}
+}
+
+// WithDialOptions 追加 grpc.DialOption, 如 TLS 证书, 会覆盖默认的同类设置
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *GrpcOption) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// WithUnaryInterceptors 按顺序链接拦截器, 内置拦截器见 grpc_interceptor.ClientUnary
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(opts *GrpcOption) {
+		opts.unaryInterceptors = append(opts.unaryInterceptors, interceptors...)
+	}
+}
+
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(opts *GrpcOption) {
+		opts.streamInterceptors = append(opts.streamInterceptors, interceptors...)
+	}
+}
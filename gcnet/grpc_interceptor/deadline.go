@@ -0,0 +1,44 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"time"
+)
+
+// timeoutOf 返回方法的超时时间, 未配置时使用 def, 为 0 表示不限制
+func timeoutOf(timeouts map[string]time.Duration, def time.Duration, method string) time.Duration {
+	if d, ok := timeouts[method]; ok {
+		return d
+	}
+	return def
+}
+
+// Deadline 服务端按方法限制处理时间, key 为完整方法名如 "/grpc.health.v1.Health/Check"
+// 客户端传来更早的截止时间时以客户端为准
+func Deadline(timeouts map[string]time.Duration, def time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		d := timeoutOf(timeouts, def, info.FullMethod)
+		if d <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// DeadlineClient 客户端按方法设置默认超时, ctx 已有截止时间时不修改
+func DeadlineClient(timeouts map[string]time.Duration, def time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		d := timeoutOf(timeouts, def, method)
+		if _, ok := ctx.Deadline(); ok || d <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,43 @@
+package grpc_interceptor
+
+import (
+	"google.golang.org/grpc"
+)
+
+// ServerUnary 默认的服务端拦截器, 依次为请求 ID, 日志, errcode 转换, panic 恢复
+// 用法 grpc_server.WithUnaryInterceptors(grpc_interceptor.ServerUnary()...)
+func ServerUnary() []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		RequestIDUnary(),
+		LoggingUnary(),
+		ErrCodeUnary(),
+		RecoveryUnary(),
+	}
+}
+
+func ServerStream() []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		RequestIDStream(),
+		LoggingStream(),
+		ErrCodeStream(),
+		RecoveryStream(),
+	}
+}
+
+// ClientUnary 默认的客户端拦截器, 依次为 errcode 还原, 请求 ID, 日志
+// 用法 grpc_client.WithUnaryInterceptors(grpc_interceptor.ClientUnary()...)
+func ClientUnary() []grpc.UnaryClientInterceptor {
+	return []grpc.UnaryClientInterceptor{
+		ErrCodeUnaryClient(),
+		RequestIDUnaryClient(),
+		LoggingUnaryClient(),
+	}
+}
+
+func ClientStream() []grpc.StreamClientInterceptor {
+	return []grpc.StreamClientInterceptor{
+		ErrCodeStreamClient(),
+		RequestIDStreamClient(),
+		LoggingStreamClient(),
+	}
+}
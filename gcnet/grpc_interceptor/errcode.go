@@ -0,0 +1,105 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"errors"
+	"github.com/v587-zyf/gc/errcode"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// grpcCodes errcode 对应的 grpc 状态码, 未列出的为 codes.Unknown
+var grpcCodes = map[errcode.ErrCode]codes.Code{
+	errcode.ERR_PARAM:                 codes.InvalidArgument,
+	errcode.ERR_SIGN:                  codes.Unauthenticated,
+	errcode.ERR_NET_NOT_LOGIN:         codes.Unauthenticated,
+	errcode.ERR_NET_RATE_LIMIT:        codes.ResourceExhausted,
+	errcode.ERR_NET_SEND_TIMEOUT:      codes.DeadlineExceeded,
+	errcode.ERR_NET_MSG_NOT_FOUND:     codes.Unimplemented,
+	errcode.ERR_NET_RELAY_UNAVAILABLE: codes.Unavailable,
+	errcode.ERR_SERVER_INTERNAL:       codes.Internal,
+	errcode.ERR_USER_DATA_NOT_FOUND:   codes.NotFound,
+	errcode.ERR_MONGO_FIND:            codes.NotFound,
+}
+
+// ToStatus errcode 转为 grpc status, 错误码放在 details 中, 其他错误原样返回
+func ToStatus(err error) error {
+	var code errcode.ErrCode
+	if err == nil || !errors.As(err, &code) {
+		return err
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	c, ok := grpcCodes[code]
+	if !ok {
+		c = codes.Unknown
+	}
+	st, detailErr := status.New(c, code.Error()).WithDetails(wrapperspb.Int32(int32(code)))
+	if detailErr != nil {
+		return status.Error(c, code.Error())
+	}
+
+	return st.Err()
+}
+
+// FromStatus 从 grpc status 的 details 中还原 errcode, 没有时原样返回
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return err
+	}
+
+	for _, d := range st.Details() {
+		if v, ok := d.(*wrapperspb.Int32Value); ok {
+			return errcode.ErrCode(v.GetValue())
+		}
+	}
+
+	return err
+}
+
+func ErrCodeUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, ToStatus(err)
+	}
+}
+
+func ErrCodeStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return ToStatus(handler(srv, ss))
+	}
+}
+
+func ErrCodeUnaryClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromStatus(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return FromStatus(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	return FromStatus(s.ClientStream.RecvMsg(m))
+}
+
+func ErrCodeStreamClient() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, FromStatus(err)
+		}
+
+		return &clientStream{ClientStream: cs}, nil
+	}
+}
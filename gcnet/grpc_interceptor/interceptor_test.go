@@ -0,0 +1,95 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+// testServer 按 Service 字段决定处理结果
+type testServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	requestID chan string
+}
+
+func (s *testServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	switch req.Service {
+	case "panic":
+		panic("test panic")
+	case "param":
+		return nil, errcode.ERR_PARAM
+	case "slow":
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+
+	s.requestID <- RequestID(ctx)
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func TestInterceptor(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	lis := bufconn.Listen(1 << 20)
+	srv := &testServer{requestID: make(chan string, 1)}
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(append(ServerUnary(),
+			Deadline(map[string]time.Duration{grpc_health_v1.Health_Check_FullMethodName: 50 * time.Millisecond}, 0))...),
+		grpc.ChainStreamInterceptor(ServerStream()...))
+	grpc_health_v1.RegisterHealthServer(s, srv)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	cc, err := grpc.NewClient("passthrough:bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(ClientUnary()...),
+		grpc.WithChainStreamInterceptor(ClientStream()...))
+	as.NoError(err)
+	defer cc.Close()
+	client := grpc_health_v1.NewHealthClient(cc)
+
+	// 请求 ID 传递到服务端, 未指定时自动生成
+	ctx := NewRequestIDCtx(context.Background(), "req-1")
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	as.NoError(err)
+	as.Equal("req-1", <-srv.requestID)
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	as.NoError(err)
+	as.NotEmpty(<-srv.requestID)
+
+	// errcode 经 status 还原
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "param"})
+	as.Equal(errcode.ERR_PARAM, err)
+	as.Equal(codes.InvalidArgument, status.Code(ToStatus(errcode.ERR_PARAM)))
+
+	// panic 转为 codes.Internal
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "panic"})
+	as.Equal(errcode.ERR_SERVER_INTERNAL, err)
+
+	// 服务端按方法限制处理时间
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "slow"})
+	as.Equal(codes.DeadlineExceeded, status.Code(err))
+
+	// 流式请求同样经过拦截器
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	as.NoError(err)
+	_, err = stream.Recv()
+	as.Equal(codes.Unimplemented, status.Code(err))
+}
@@ -0,0 +1,63 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// logCall 成功时记 Debug, 失败时记 Warn
+func logCall(ctx context.Context, msg, method string, start time.Time, err error) {
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("requestID", RequestID(ctx)),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("code", status.Code(err).String()),
+	}
+	if err != nil {
+		log.Warn(msg, append(fields, zap.Error(err))...)
+		return
+	}
+	log.Debug(msg, fields...)
+}
+
+// LoggingUnary 记录请求耗时和结果, 放在 RequestIDUnary 之后才能记录请求 ID
+func LoggingUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, "grpc server unary", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func LoggingStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), "grpc server stream", info.FullMethod, start, err)
+		return err
+	}
+}
+
+func LoggingUnaryClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logCall(ctx, "grpc client unary", method, start, err)
+		return err
+	}
+}
+
+// LoggingStreamClient 只记录建立流的结果
+func LoggingStreamClient() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		logCall(ctx, "grpc client stream", method, start, err)
+		return cs, err
+	}
+}
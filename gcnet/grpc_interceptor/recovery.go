@@ -0,0 +1,41 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"runtime/debug"
+)
+
+func recovered(method string, r any) error {
+	log.Error("grpc panic", zap.String("method", method), zap.Any("r", r), zap.String("stack", string(debug.Stack())))
+
+	return ToStatus(errcode.ERR_SERVER_INTERNAL)
+}
+
+// RecoveryUnary handler panic 时返回 codes.Internal
+func RecoveryUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func RecoveryStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,68 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type requestIDKey struct{}
+
+// NewRequestIDCtx 指定之后 grpc 请求使用的请求 ID
+func NewRequestIDCtx(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 ctx 中的请求 ID, 服务端经 RequestIDUnary 后可取到客户端传来的 ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// incomingRequestID 取客户端传来的请求 ID, 没有时生成
+func incomingRequestID(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(enums.GRPC_MD_REQUEST_ID); len(v) > 0 && v[0] != "" {
+			return NewRequestIDCtx(ctx, v[0])
+		}
+	}
+
+	return NewRequestIDCtx(ctx, utils.GUID())
+}
+
+// outgoingRequestID 把 ctx 中的请求 ID 放入 metadata, 没有时生成, 链路上的服务共用同一个 ID
+func outgoingRequestID(ctx context.Context) context.Context {
+	id := RequestID(ctx)
+	if id == "" {
+		id = utils.GUID()
+		ctx = NewRequestIDCtx(ctx, id)
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, enums.GRPC_MD_REQUEST_ID, id)
+}
+
+func RequestIDUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(incomingRequestID(ctx), req)
+	}
+}
+
+func RequestIDStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: incomingRequestID(ss.Context())})
+	}
+}
+
+func RequestIDUnaryClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+func RequestIDStreamClient() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,17 @@
+package grpc_interceptor
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// serverStream 替换流的 ctx
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -1,7 +1,19 @@
 package grpc_server
 
+import (
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/grpc"
+)
+
 type GrpcOption struct {
 	listenAddr string
+
+	registry iface.IRegistry
+	instance *iface.ServiceInstance
+
+	serverOptions      []grpc.ServerOption
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
 }
 
 type Option func(opts *GrpcOption)
@@ -17,3 +29,32 @@ func WithListenAddr(addr string) Option {
 		opts.listenAddr = addr
 	}
 }
+
+// WithRegistry Start 时注册并续约, Stop 时注销
+// ins.Addr 为空时使用监听地址, 监听 0.0.0.0 等地址时需指定其他服务器可访问的地址
+func WithRegistry(reg iface.IRegistry, ins *iface.ServiceInstance) Option {
+	return func(opts *GrpcOption) {
+		opts.registry = reg
+		opts.instance = ins
+	}
+}
+
+// WithServerOptions 追加 grpc.ServerOption, 如 TLS 证书, 消息大小限制
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *GrpcOption) {
+		o.serverOptions = append(o.serverOptions, opts...)
+	}
+}
+
+// WithUnaryInterceptors 按顺序链接拦截器, 内置拦截器见 grpc_interceptor.ServerUnary
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(opts *GrpcOption) {
+		opts.unaryInterceptors = append(opts.unaryInterceptors, interceptors...)
+	}
+}
+
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) Option {
+	return func(opts *GrpcOption) {
+		opts.streamInterceptors = append(opts.streamInterceptors, interceptors...)
+	}
+}
@@ -2,6 +2,8 @@ package grpc_server
 
 import (
 	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/iface"
 	"github.com/v587-zyf/gc/log"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -18,6 +20,8 @@ type GrpcServer struct {
 
 	listener net.Listener
 	server   *grpc.Server
+
+	instance *iface.ServiceInstance
 }
 
 func NewGrpcServer() *GrpcServer {
@@ -49,10 +53,18 @@ func (s *GrpcServer) Init(ctx context.Context, option ...any) (err error) {
 		Time:    30 * time.Second,
 		Timeout: 20 * time.Second,
 	}
-	s.server = grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.KeepaliveEnforcementPolicy(keepalivePolicy),
 		grpc.KeepaliveParams(keepaliveOptions),
-	)
+	}
+	if len(s.options.unaryInterceptors) > 0 {
+		opts = append(opts, grpc.ChainUnaryInterceptor(s.options.unaryInterceptors...))
+	}
+	if len(s.options.streamInterceptors) > 0 {
+		opts = append(opts, grpc.ChainStreamInterceptor(s.options.streamInterceptors...))
+	}
+	opts = append(opts, s.options.serverOptions...)
+	s.server = grpc.NewServer(opts...)
 
 	return nil
 }
@@ -65,6 +77,18 @@ func (s *GrpcServer) GetCtx() context.Context {
 }
 
 func (s *GrpcServer) Start() {
+	if s.options.registry != nil {
+		ins := *s.options.instance
+		if ins.Addr == "" {
+			ins.Addr = s.listener.Addr().String()
+		}
+		if err := s.options.registry.Register(s.ctx, &ins); err != nil {
+			log.Error("grpc server register err", zap.Error(err))
+		} else {
+			s.instance = &ins
+		}
+	}
+
 	err := s.server.Serve(s.listener)
 	if err != nil {
 		log.Error("grpc server start err", zap.Error(err))
@@ -73,6 +97,14 @@ func (s *GrpcServer) Start() {
 }
 
 func (s *GrpcServer) Stop() {
+	if s.instance != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), enums.SERVER_SHUTDOWN_TIMEOUT)
+		if err := s.options.registry.Deregister(ctx, s.instance); err != nil {
+			log.Error("grpc server deregister err", zap.Error(err))
+		}
+		cancel()
+	}
+
 	s.listener.Close()
 	s.server.Stop()
 }
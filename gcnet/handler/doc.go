@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/protobuf/proto"
+)
+
+var defHandler *Handler
+
+func Init(ctx context.Context, opts ...Option) (err error) {
+	defHandler = NewHandler()
+	if err = defHandler.Init(ctx, opts...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Get() *Handler {
+	return defHandler
+}
+
+func GetCtx() context.Context {
+	return defHandler.ctx
+}
+
+func Register(msgID uint32, handler iface.SessionRecv) {
+	defHandler.Register(msgID, handler)
+}
+
+func GetHandler(msgID uint32) iface.SessionRecv {
+	return defHandler.GetHandler(msgID)
+}
+
+func HasHandler(msgID uint32) bool {
+	return defHandler.HasHandler(msgID)
+}
+
+func Use(interceptors ...Interceptor) {
+	defHandler.Use(interceptors...)
+}
+
+func RegisterRoute[T any, PT interface {
+	*T
+	proto.Message
+}](msgID, respMsgID uint16, fn func(ss iface.ISession, req PT) (resp proto.Message, err error)) {
+	Route[T, PT](defHandler, msgID, respMsgID, fn)
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// routeFn 使用 Dispatch 已解析的包头和消息体处理消息, 返回的错误交给拦截器链
+type routeFn func(ss iface.ISession, frame *iface.MessageFrame, body []byte) error
+
+type HandlerUnit struct {
+	msgID   uint32
+	handler iface.SessionRecv
+	newMsg  func() proto.Message
+	route   routeFn
+}
+type Handler struct {
+	options *HandlerOption
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	handlers map[uint32]*HandlerUnit
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		options:  NewHandlerOption(),
+		handlers: make(map[uint32]*HandlerUnit),
+	}
+}
+
+func (h *Handler) Init(ctx context.Context, option ...Option) (err error) {
+	h.ctx, h.cancel = context.WithCancel(ctx)
+
+	for _, opt := range option {
+		opt(h.options)
+	}
+
+	return nil
+}
+
+func (h *Handler) Name() string {
+	name := ""
+	if h.options.name != "" {
+		name = h.options.name
+	}
+	return name
+}
+
+func (h *Handler) Register(msgID uint32, handler iface.SessionRecv) {
+	h.handlers[msgID] = &HandlerUnit{
+		msgID:   msgID,
+		handler: handler,
+	}
+}
+
+// register 注册 Route 的处理函数, handler 供 GetHandler 直接调用
+func (h *Handler) register(msgID uint32, newMsg func() proto.Message, route routeFn) {
+	h.handlers[msgID] = &HandlerUnit{
+		msgID: msgID,
+		handler: func(ss iface.ISession, data any) {
+			buf, ok := data.([]byte)
+			if !ok {
+				log.Warn("handler route data type err", zap.Uint32("msgID", msgID))
+				return
+			}
+			frame, body, err := codec.UnpackWith(h.options.codec, buf)
+			if err != nil {
+				log.Warn("handler unpack err", zap.Uint64("userID", ss.GetID()), zap.Error(err))
+				return
+			}
+			if err = route(ss, frame, body); err != nil {
+				h.ReplyErr(ss, frame.Tag, err)
+			}
+		},
+		newMsg: newMsg,
+		route:  route,
+	}
+}
+
+func (h *Handler) GetHandler(msgID uint32) iface.SessionRecv {
+	if h, ok := h.handlers[msgID]; ok {
+		return h.handler
+	}
+	return nil
+}
+
+func (h *Handler) HasHandler(msgID uint32) bool {
+	_, ok := h.handlers[msgID]
+	return ok
+}
+
+// NewMsg 返回 msgID 对应的空请求消息, 未通过 Route 注册时返回 nil
+func (h *Handler) NewMsg(msgID uint32) proto.Message {
+	if u, ok := h.handlers[msgID]; ok && u.newMsg != nil {
+		return u.newMsg()
+	}
+	return nil
+}
+
+func (h *Handler) Start(ss iface.ISession) {
+	if h.options.startFn != nil {
+		h.options.startFn(ss)
+	}
+}
+
+func (h *Handler) Recv(ss iface.ISession, data any) {
+	if h.options.recvFn != nil {
+		h.options.recvFn(ss, data)
+		return
+	}
+
+	h.Dispatch(ss, data)
+}
+
+// Dispatch 解析包头并按 msgID 经拦截器链调用已注册的 handler
+func (h *Handler) Dispatch(ss iface.ISession, data any) {
+	buf, ok := data.([]byte)
+	if !ok {
+		log.Warn("handler dispatch data type err", zap.Uint64("userID", ss.GetID()))
+		return
+	}
+
+	frame, body, err := codec.UnpackWith(h.options.codec, buf)
+	if err != nil {
+		log.Warn("handler unpack err", zap.Uint64("userID", ss.GetID()), zap.Error(err))
+		return
+	}
+
+	u, ok := h.handlers[uint32(frame.MsgID)]
+	if !ok {
+		log.Warn("handler handler not found", zap.Uint64("userID", ss.GetID()), zap.Uint16("msgID", frame.MsgID))
+		h.ReplyErr(ss, frame.Tag, errcode.ERR_NET_MSG_NOT_FOUND)
+		return
+	}
+
+	err = chain(h.options.interceptors, ss, frame, func() error {
+		if u.route != nil {
+			return u.route(ss, frame, body)
+		}
+		u.handler(ss, buf)
+		return nil
+	})
+	if err != nil {
+		h.ReplyErr(ss, frame.Tag, err)
+	}
+}
+
+// Use 追加拦截器, 需在开始处理消息前调用
+func (h *Handler) Use(interceptors ...Interceptor) {
+	h.options.interceptors = append(h.options.interceptors, interceptors...)
+}
+
+func (h *Handler) Reply(ss iface.ISession, msgID uint16, tag uint32, msg proto.Message) error {
+	data, err := codec.PackProtoWith(h.options.codec, msgID, tag, ss.GetID(), msg)
+	if err != nil {
+		log.Error("handler pack err", zap.Uint16("msgID", msgID), zap.Error(err))
+		return err
+	}
+
+	return ss.SendMsg(func(args ...any) ([]byte, error) {
+		return data, nil
+	})
+}
+
+func (h *Handler) ReplyErr(ss iface.ISession, tag uint32, err error) error {
+	var code errcode.ErrCode
+	if !errors.As(err, &code) {
+		code = errcode.ERR_STANDARD_ERR
+	}
+
+	if h.options.errReplyFn == nil {
+		log.Warn("handler reply err", zap.Uint64("userID", ss.GetID()), zap.Error(err))
+		return nil
+	}
+
+	msgID, msg := h.options.errReplyFn(code)
+	return h.Reply(ss, msgID, tag, msg)
+}
+
+func (h *Handler) Stop(ss iface.ISession) {
+	if h.options.stopFn != nil {
+		h.options.stopFn(ss)
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrReplyFn 根据错误码构造回复给客户端的错误消息
+type ErrReplyFn func(code errcode.ErrCode) (msgID uint16, msg proto.Message)
+
+type HandlerOption struct {
+	name    string
+	startFn func(s iface.ISession)
+	recvFn  func(s iface.ISession, data any)
+	stopFn  func(s iface.ISession)
+
+	codec      iface.ICodec
+	errReplyFn ErrReplyFn
+
+	interceptors []Interceptor
+}
+
+type Option func(opts *HandlerOption)
+
+func NewHandlerOption() *HandlerOption {
+	o := &HandlerOption{
+		codec: codec.Get(),
+	}
+
+	return o
+}
+
+func WithName(name string) Option {
+	return func(opts *HandlerOption) {
+		opts.name = name
+	}
+}
+
+func WithStartFn(fn func(s iface.ISession)) Option {
+	return func(opts *HandlerOption) {
+		opts.startFn = fn
+	}
+}
+
+func WithRecvFn(fn func(s iface.ISession, data any)) Option {
+	return func(opts *HandlerOption) {
+		opts.recvFn = fn
+	}
+}
+
+func WithStopFn(fn func(s iface.ISession)) Option {
+	return func(opts *HandlerOption) {
+		opts.stopFn = fn
+	}
+}
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *HandlerOption) {
+		if c != nil {
+			opts.codec = c
+		}
+	}
+}
+
+func WithErrReplyFn(fn ErrReplyFn) Option {
+	return func(opts *HandlerOption) {
+		opts.errReplyFn = fn
+	}
+}
+
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(opts *HandlerOption) {
+		opts.interceptors = append(opts.interceptors, interceptors...)
+	}
+}
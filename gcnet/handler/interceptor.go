@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Interceptor 消息拦截器, 按注册顺序执行
+// 不调用 next 即中断后续处理, 返回的错误会通过 ReplyErr 回复给客户端
+type Interceptor func(ss iface.ISession, frame *iface.MessageFrame, next func() error) error
+
+// chain handler 返回的错误经拦截器链返回, Logging 等拦截器可以看到处理结果
+func chain(interceptors []Interceptor, ss iface.ISession, frame *iface.MessageFrame, handler func() error) error {
+	var call func(i int) error
+	call = func(i int) error {
+		if i == len(interceptors) {
+			return handler()
+		}
+		return interceptors[i](ss, frame, func() error {
+			return call(i + 1)
+		})
+	}
+
+	return call(0)
+}
+
+// Recovery 捕获 handler 的 panic, 回复 ERR_SERVER_INTERNAL
+func Recovery() Interceptor {
+	return func(ss iface.ISession, frame *iface.MessageFrame, next func() error) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("handler panic", zap.Uint64("userID", ss.GetID()), zap.Uint16("msgID", frame.MsgID),
+					zap.Any("r", r), zap.String("stack", string(debug.Stack())))
+				err = errcode.ERR_SERVER_INTERNAL
+			}
+		}()
+
+		return next()
+	}
+}
+
+// Logging 记录消息处理耗时, 超过 slow 时输出 warn
+func Logging(slow time.Duration) Interceptor {
+	return func(ss iface.ISession, frame *iface.MessageFrame, next func() error) error {
+		start := time.Now()
+		err := next()
+		cost := time.Since(start)
+
+		fields := []zap.Field{zap.Uint64("userID", ss.GetID()), zap.Uint16("msgID", frame.MsgID),
+			zap.Uint32("tag", frame.Tag), zap.Duration("cost", cost)}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		if slow > 0 && cost > slow {
+			log.Warn("handler slow msg", fields...)
+		} else {
+			log.Debug("handler msg", fields...)
+		}
+
+		return err
+	}
+}
+
+// MustLogin 未登录(userID 为 0)的会话只允许发送 skip 中的消息
+func MustLogin(skip ...uint16) Interceptor {
+	skipMap := make(map[uint16]struct{}, len(skip))
+	for _, msgID := range skip {
+		skipMap[msgID] = struct{}{}
+	}
+
+	return func(ss iface.ISession, frame *iface.MessageFrame, next func() error) error {
+		if _, ok := skipMap[frame.MsgID]; !ok && ss.GetID() == 0 {
+			return errcode.ERR_NET_NOT_LOGIN
+		}
+
+		return next()
+	}
+}
+
+const rateLimiterKey = "handler_rate_limiter"
+
+// RateLimit 按会话和 msgID 限流, r 为每秒允许的消息数
+func RateLimit(r float64, burst int) Interceptor {
+	return func(ss iface.ISession, frame *iface.MessageFrame, next func() error) error {
+		var limiters *sync.Map
+		if v, ok := ss.Get(rateLimiterKey); ok {
+			limiters = v.(*sync.Map)
+		} else {
+			limiters = new(sync.Map)
+			ss.Set(rateLimiterKey, limiters)
+		}
+
+		v, _ := limiters.LoadOrStore(frame.MsgID, rate.NewLimiter(rate.Limit(r), burst))
+		if !v.(*rate.Limiter).Allow() {
+			return errcode.ERR_NET_RATE_LIMIT
+		}
+
+		return next()
+	}
+}
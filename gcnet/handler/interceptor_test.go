@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+)
+
+func TestInterceptor(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	var order []string
+	mark := func(name string) Interceptor {
+		return func(ss iface.ISession, frame *iface.MessageFrame, next func() error) error {
+			order = append(order, name)
+			return next()
+		}
+	}
+
+	h := NewHandler()
+	as.NoError(h.Init(context.Background(),
+		WithErrReplyFn(func(code errcode.ErrCode) (uint16, proto.Message) {
+			return 999, wrapperspb.Int32(code.Int32())
+		}),
+		WithInterceptors(mark("a"), Recovery()),
+	))
+	h.Use(MustLogin(1), mark("b"))
+
+	h.Register(1, func(ss iface.ISession, data any) {
+		order = append(order, "login")
+	})
+	h.Register(2, func(ss iface.ISession, data any) {
+		panic("boom")
+	})
+
+	ss := &testSession{}
+	h.Recv(ss, codec.Pack(1, 1, 0, nil))
+	as.Equal([]string{"a", "b", "login"}, order)
+	as.Len(ss.sent, 0)
+
+	// testSession.GetID 恒为 7, 视为已登录, panic 被 Recovery 捕获
+	h.Recv(ss, codec.Pack(2, 2, 0, nil))
+	as.Len(ss.sent, 1)
+	frame, body, err := codec.Unpack(ss.sent[0])
+	as.NoError(err)
+	as.Equal(uint32(2), frame.Tag)
+	code := &wrapperspb.Int32Value{}
+	as.NoError(proto.Unmarshal(body, code))
+	as.Equal(errcode.ERR_SERVER_INTERNAL.Int32(), code.GetValue())
+}
+
+func TestInterceptorRouteErr(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	var errs []error
+	h := NewHandler()
+	as.NoError(h.Init(context.Background(),
+		WithErrReplyFn(func(code errcode.ErrCode) (uint16, proto.Message) {
+			return 999, wrapperspb.Int32(code.Int32())
+		}),
+		WithInterceptors(func(ss iface.ISession, frame *iface.MessageFrame, next func() error) error {
+			err := next()
+			errs = append(errs, err)
+			return err
+		}),
+	))
+	Route(h, 1, 2, func(ss iface.ISession, req *wrapperspb.StringValue) (proto.Message, error) {
+		return nil, errcode.ERR_PARAM
+	})
+
+	// Route 返回的错误经过拦截器, 只回复一次
+	data, err := codec.PackProto(1, 3, 0, wrapperspb.String("hi"))
+	as.NoError(err)
+	ss := &testSession{}
+	h.Recv(ss, data)
+	as.Equal([]error{errcode.ERR_PARAM}, errs)
+	as.Len(ss.sent, 1)
+}
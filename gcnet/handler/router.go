@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// Route 注册带类型的消息处理函数
+// 收到 msgID 后解析为 *T 调用 fn, fn 返回的 resp 以 respMsgID 和请求相同的 Tag 回复
+// fn 返回的错误经拦截器链后通过 WithErrReplyFn 构造错误消息回复
+func Route[T any, PT interface {
+	*T
+	proto.Message
+}](h *Handler, msgID, respMsgID uint16, fn func(ss iface.ISession, req PT) (resp proto.Message, err error)) {
+	newMsg := func() proto.Message {
+		return PT(new(T))
+	}
+
+	h.register(uint32(msgID), newMsg, func(ss iface.ISession, frame *iface.MessageFrame, body []byte) error {
+		req := PT(new(T))
+		if err := proto.Unmarshal(body, req); err != nil {
+			log.Warn("handler unmarshal err", zap.Uint64("userID", ss.GetID()),
+				zap.Uint16("msgID", msgID), zap.Error(err))
+			return errcode.ERR_PARAM
+		}
+		frame.Body = req
+
+		resp, err := fn(ss, req)
+		if err != nil {
+			return err
+		}
+		if resp != nil {
+			h.Reply(ss, respMsgID, frame.Tag, resp)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+	"time"
+)
+
+type testSession struct {
+	iface.ISession
+	sent [][]byte
+}
+
+func (s *testSession) GetID() uint64 { return 7 }
+
+func (s *testSession) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	data, err := fn(args...)
+	if err != nil {
+		return err
+	}
+	s.sent = append(s.sent, data)
+	return nil
+}
+
+func (s *testSession) IsHeartbeatTimeout(now time.Time) bool { return false }
+
+func TestRoute(t *testing.T) {
+	var as = assert.New(t)
+
+	h := NewHandler()
+	as.NoError(h.Init(context.Background(), WithErrReplyFn(func(code errcode.ErrCode) (uint16, proto.Message) {
+		return 999, wrapperspb.Int32(code.Int32())
+	})))
+
+	Route(h, 1, 2, func(ss iface.ISession, req *wrapperspb.StringValue) (proto.Message, error) {
+		if req.GetValue() == "" {
+			return nil, errcode.ERR_PARAM
+		}
+		return wrapperspb.String("hi " + req.GetValue()), nil
+	})
+	as.True(h.HasHandler(1))
+	as.IsType(&wrapperspb.StringValue{}, h.NewMsg(1))
+
+	ss := &testSession{}
+	req, err := codec.PackProto(1, 42, 0, wrapperspb.String("bob"))
+	as.NoError(err)
+	h.Recv(ss, req)
+
+	errReq, err := codec.PackProto(1, 43, 0, wrapperspb.String(""))
+	as.NoError(err)
+	h.Recv(ss, errReq)
+
+	as.Len(ss.sent, 2)
+
+	frame, body, err := codec.Unpack(ss.sent[0])
+	as.NoError(err)
+	as.Equal(uint16(2), frame.MsgID)
+	as.Equal(uint32(42), frame.Tag)
+	as.Equal(uint64(7), frame.UserID)
+	resp := &wrapperspb.StringValue{}
+	as.NoError(proto.Unmarshal(body, resp))
+	as.Equal("hi bob", resp.GetValue())
+
+	frame, body, err = codec.Unpack(ss.sent[1])
+	as.NoError(err)
+	as.Equal(uint16(999), frame.MsgID)
+	as.Equal(uint32(43), frame.Tag)
+	code := &wrapperspb.Int32Value{}
+	as.NoError(proto.Unmarshal(body, code))
+	as.Equal(errcode.ERR_PARAM.Int32(), code.GetValue())
+}
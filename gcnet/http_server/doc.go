@@ -3,6 +3,7 @@ package http_server
 import (
 	"context"
 	"github.com/gofiber/fiber/v2"
+	"net/http"
 )
 
 var defHttpServer *HttpServer
@@ -39,6 +40,10 @@ func Stop() {
 	defHttpServer.Stop()
 }
 
+func Shutdown(ctx context.Context) error {
+	return defHttpServer.Shutdown(ctx)
+}
+
 func Wait() error {
 	return defHttpServer.Wait()
 }
@@ -59,6 +64,10 @@ func GetOrigin(path string, fn OriginHandlerFn) {
 	defHttpServer.GetOrigin(path, fn)
 }
 
+func Handle(method, path string, h http.Handler) {
+	defHttpServer.Handle(method, path, h)
+}
+
 func Use(fn OriginHandlerFn) {
 	defHttpServer.Use(fn)
 }
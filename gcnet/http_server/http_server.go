@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"kernel/tools"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"go.uber.org/zap"
 
+	"github.com/v587-zyf/gc/enums"
 	"github.com/v587-zyf/gc/log"
 )
 
@@ -135,7 +138,31 @@ func (s *HttpServer) Start() {
 	return
 }
 
-func (s *HttpServer) Stop() {}
+func (s *HttpServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), enums.SERVER_SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	s.Shutdown(ctx)
+}
+
+// Shutdown 停止接受新连接并在 ctx 结束前等待处理中的请求完成
+func (s *HttpServer) Shutdown(ctx context.Context) (err error) {
+	// 未 Init 时无需关闭
+	if s.app == nil || s.cancel == nil {
+		return
+	}
+	if s.ln == nil {
+		s.cancel()
+		return
+	}
+
+	if err = s.app.ShutdownWithContext(ctx); err != nil {
+		log.Warn("http_server shutdown err", zap.Error(err))
+	}
+	s.cancel()
+
+	return
+}
 
 func (s *HttpServer) Wait() error {
 	s.wg.Wait()
@@ -159,6 +186,11 @@ func (s *HttpServer) GetOrigin(path string, fn OriginHandlerFn) {
 	s.app.Get(path, NewOriginHandlerFn(fn))
 }
 
+// Handle 挂载标准库 http.Handler, 如 go_tg_bot.WebhookHandler()
+func (s *HttpServer) Handle(method, path string, h http.Handler) {
+	s.app.Add(method, path, adaptor.HTTPHandler(h))
+}
+
 func (s *HttpServer) Use(fn OriginHandlerFn) {
 	s.app.Use(NewOriginHandlerFn(fn))
 }
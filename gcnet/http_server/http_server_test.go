@@ -0,0 +1,23 @@
+package http_server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/log"
+	"testing"
+)
+
+func TestStopWithoutInit(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	as.NotPanics(func() { (&HttpServer{}).Stop() })
+	as.NotPanics(func() { NewHttpServer().Stop() })
+
+	// 监听失败时 Init 后的 Stop 同样安全
+	s := NewHttpServer()
+	s.Init(context.Background(), WithListenAddr("bad addr"))
+	as.NotPanics(s.Stop)
+}
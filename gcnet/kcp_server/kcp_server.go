@@ -0,0 +1,102 @@
+package kcp_server
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/gcnet/tcp_client"
+	"github.com/v587-zyf/gc/gcnet/tcp_server"
+	"github.com/xtaci/kcp-go/v5"
+	"net"
+	"time"
+)
+
+// kcp 连接实现 net.Conn, 交给 tcp_server 后产生的仍是 tcp_session.Session,
+// Hooks、handler 和 session_mgr 无需修改
+// udp 没有断开通知, 需配合 tcp_server.WithHeartbeat 清理失联的会话
+
+// WithKcp tcp_server 改用 kcp 监听, 如 tcp_server.Init(ctx, tcp_server.WithListenAddr(addr), kcp_server.WithKcp())
+func WithKcp(opts ...Option) tcp_server.Option {
+	return tcp_server.WithListenFn(func(ctx context.Context, addr string) (net.Listener, error) {
+		return Listen(addr, opts...)
+	})
+}
+
+// WithKcpDial tcp_client 改用 kcp 连接
+func WithKcpDial(opts ...Option) tcp_client.Option {
+	return tcp_client.WithDialFn(func(ctx context.Context, addr string, timeout time.Duration) (net.Conn, error) {
+		return Dial(addr, opts...)
+	})
+}
+
+type listener struct {
+	*kcp.Listener
+
+	options *KcpOption
+}
+
+// Listen 创建 kcp 监听, Accept 返回的连接已按 opts 设置
+func Listen(addr string, opts ...Option) (net.Listener, error) {
+	o := NewKcpOption()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	l, err := kcp.ListenWithOptions(addr, nil, o.dataShards, o.parityShards)
+	if err != nil {
+		return nil, err
+	}
+	if o.readBuffer > 0 {
+		l.SetReadBuffer(o.readBuffer)
+	}
+	if o.writeBuffer > 0 {
+		l.SetWriteBuffer(o.writeBuffer)
+	}
+	if o.dscp > 0 {
+		l.SetDSCP(o.dscp)
+	}
+
+	return &listener{Listener: l, options: o}, nil
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	s, err := l.AcceptKCP()
+	if err != nil {
+		return nil, err
+	}
+	l.options.apply(s)
+
+	return s, nil
+}
+
+// Dial 连接 kcp 服务器
+func Dial(addr string, opts ...Option) (net.Conn, error) {
+	o := NewKcpOption()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s, err := kcp.DialWithOptions(addr, nil, o.dataShards, o.parityShards)
+	if err != nil {
+		return nil, err
+	}
+	if o.readBuffer > 0 {
+		s.SetReadBuffer(o.readBuffer)
+	}
+	if o.writeBuffer > 0 {
+		s.SetWriteBuffer(o.writeBuffer)
+	}
+	if o.dscp > 0 {
+		s.SetDSCP(o.dscp)
+	}
+	o.apply(s)
+
+	return s, nil
+}
+
+func (o *KcpOption) apply(s *kcp.UDPSession) {
+	s.SetNoDelay(o.noDelay, o.interval, o.resend, o.nc)
+	s.SetWindowSize(o.sndWnd, o.rcvWnd)
+	s.SetMtu(o.mtu)
+	s.SetStreamMode(o.streamMode)
+	s.SetWriteDelay(o.writeDelay)
+	s.SetACKNoDelay(o.ackNoDelay)
+}
@@ -0,0 +1,75 @@
+package kcp_server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/tcp_client"
+	"github.com/v587-zyf/gc/gcnet/tcp_server"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"net"
+	"testing"
+	"time"
+)
+
+// echoMethod 原样回复收到的帧
+type echoMethod struct{}
+
+func (echoMethod) Name() string { return "echo" }
+
+func (echoMethod) Start(ss iface.ISession) {}
+
+func (echoMethod) Recv(ss iface.ISession, data any) {
+	msg := append([]byte(nil), data.([]byte)...)
+	ss.SendMsg(func(args ...any) ([]byte, error) {
+		return msg, nil
+	})
+}
+
+func (echoMethod) Stop(ss iface.ISession) {}
+
+func TestKcpEcho(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+	as.NoError(buffer_pool.Init(context.Background()))
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	as.NoError(err)
+	addr := pc.LocalAddr().String()
+	pc.Close()
+
+	s := tcp_server.NewTcpServer()
+	as.NoError(s.Init(context.Background(), tcp_server.WithListenAddr(addr),
+		tcp_server.WithMethod(echoMethod{}), WithKcp(WithFEC(10, 3))))
+	go s.Start()
+	defer s.Stop()
+
+	recv := make(chan []byte, 1)
+	c := tcp_client.NewTcpClient()
+	c.Hooks().OnRecv(func(ss iface.ISession, data any) {
+		recv <- append([]byte(nil), data.([]byte)...)
+	})
+	as.NoError(c.Init(context.Background(), tcp_client.WithAddr(addr), WithKcpDial(WithFEC(10, 3))))
+	c.Start()
+	defer c.Stop()
+
+	for i := 0; i < 3; i++ {
+		as.NoError(c.SendMsg(func(args ...any) ([]byte, error) {
+			return codec.Pack(uint16(i+1), 0, 0, []byte{byte(i)}), nil
+		}))
+
+		select {
+		case data := <-recv:
+			frame, body, err := codec.Unpack(data)
+			as.NoError(err)
+			as.Equal(uint16(i+1), frame.MsgID)
+			as.Equal([]byte{byte(i)}, body)
+		case <-time.After(time.Second):
+			t.Fatal("recv timeout")
+		}
+	}
+}
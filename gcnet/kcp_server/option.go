@@ -0,0 +1,114 @@
+package kcp_server
+
+import (
+	"github.com/v587-zyf/gc/enums"
+)
+
+type KcpOption struct {
+	dataShards   int
+	parityShards int
+
+	noDelay  int
+	interval int
+	resend   int
+	nc       int
+
+	sndWnd int
+	rcvWnd int
+	mtu    int
+
+	streamMode bool
+	writeDelay bool
+	ackNoDelay bool
+
+	readBuffer  int
+	writeBuffer int
+	dscp        int
+}
+
+type Option func(opts *KcpOption)
+
+func NewKcpOption() *KcpOption {
+	o := &KcpOption{
+		noDelay:  enums.KCP_NO_DELAY,
+		interval: enums.KCP_INTERVAL,
+		resend:   enums.KCP_RESEND,
+		nc:       enums.KCP_NC,
+
+		sndWnd: enums.KCP_SND_WND,
+		rcvWnd: enums.KCP_RCV_WND,
+		mtu:    enums.KCP_MTU,
+
+		streamMode: true,
+		ackNoDelay: true,
+	}
+
+	return o
+}
+
+// WithFEC 前向纠错, 每 dataShards 个包附带 parityShards 个校验包, 两端需一致
+func WithFEC(dataShards, parityShards int) Option {
+	return func(opts *KcpOption) {
+		opts.dataShards = dataShards
+		opts.parityShards = parityShards
+	}
+}
+
+// WithNoDelay 对应 ikcp_nodelay, 默认极速模式 1, 10, 2, 1
+func WithNoDelay(noDelay, interval, resend, nc int) Option {
+	return func(opts *KcpOption) {
+		opts.noDelay = noDelay
+		opts.interval = interval
+		opts.resend = resend
+		opts.nc = nc
+	}
+}
+
+// WithWindowSize 发送和接收窗口大小(包数)
+func WithWindowSize(sndWnd, rcvWnd int) Option {
+	return func(opts *KcpOption) {
+		opts.sndWnd = sndWnd
+		opts.rcvWnd = rcvWnd
+	}
+}
+
+func WithMtu(mtu int) Option {
+	return func(opts *KcpOption) {
+		opts.mtu = mtu
+	}
+}
+
+// WithStreamMode 流模式下小包会合并发送, 默认开启
+func WithStreamMode(enable bool) Option {
+	return func(opts *KcpOption) {
+		opts.streamMode = enable
+	}
+}
+
+// WithWriteDelay 开启后写入的数据延迟到下次刷新时发送
+func WithWriteDelay(delay bool) Option {
+	return func(opts *KcpOption) {
+		opts.writeDelay = delay
+	}
+}
+
+// WithACKNoDelay 收到数据立即回复 ack, 默认开启
+func WithACKNoDelay(noDelay bool) Option {
+	return func(opts *KcpOption) {
+		opts.ackNoDelay = noDelay
+	}
+}
+
+// WithBufferSize udp socket 读写缓冲区大小, 0 使用系统默认
+func WithBufferSize(read, write int) Option {
+	return func(opts *KcpOption) {
+		opts.readBuffer = read
+		opts.writeBuffer = write
+	}
+}
+
+func WithDSCP(dscp int) Option {
+	return func(opts *KcpOption) {
+		opts.dscp = dscp
+	}
+}
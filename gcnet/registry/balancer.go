@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/v587-zyf/gc/enums"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"hash/crc32"
+	"slices"
+	"strconv"
+	"sync/atomic"
+)
+
+// hashReplicas 每个实例在哈希环上的虚拟节点数
+const hashReplicas = 100
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(enums.BALANCER_CONSISTENT_HASH, &hashPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type hashCtxKey struct{}
+
+// NewHashCtx 一致性哈希时按 userID 选择实例, 实例不变时同一用户总是落到同一实例上
+// 未设置时轮询
+func NewHashCtx(ctx context.Context, userID uint64) context.Context {
+	return context.WithValue(ctx, hashCtxKey{}, userID)
+}
+
+type hashNode struct {
+	hash uint32
+	sc   balancer.SubConn
+}
+
+type hashPickerBuilder struct{}
+
+func (*hashPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &hashPicker{
+		subConns: make([]balancer.SubConn, 0, len(info.ReadySCs)),
+		ring:     make([]hashNode, 0, len(info.ReadySCs)*hashReplicas),
+	}
+	for sc, sci := range info.ReadySCs {
+		// 优先用实例 ID, 地址变化不影响分布
+		key := sci.Address.Addr
+		if id, ok := sci.Address.Attributes.Value(instanceIDKey{}).(uint64); ok {
+			key = strconv.FormatUint(id, 10)
+		}
+
+		p.subConns = append(p.subConns, sc)
+		for i := 0; i < hashReplicas; i++ {
+			p.ring = append(p.ring, hashNode{hash: crc32.ChecksumIEEE([]byte(key + "#" + strconv.Itoa(i))), sc: sc})
+		}
+	}
+	slices.SortFunc(p.ring, func(a, b hashNode) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return 0
+	})
+
+	return p
+}
+
+type hashPicker struct {
+	subConns []balancer.SubConn
+	ring     []hashNode
+	next     atomic.Uint32
+}
+
+func (p *hashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	userID, ok := info.Ctx.Value(hashCtxKey{}).(uint64)
+	if !ok {
+		i := p.next.Add(1)
+		return balancer.PickResult{SubConn: p.subConns[int(i)%len(p.subConns)]}, nil
+	}
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], userID)
+	h := crc32.ChecksumIEEE(b[:])
+	idx, _ := slices.BinarySearchFunc(p.ring, h, func(n hashNode, h uint32) int {
+		switch {
+		case n.hash < h:
+			return -1
+		case n.hash > h:
+			return 1
+		}
+		return 0
+	})
+	if idx == len(p.ring) {
+		idx = 0
+	}
+
+	return balancer.PickResult{SubConn: p.ring[idx].sc}, nil
+}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/iface"
+)
+
+var defRegistry iface.IRegistry
+
+// InitRedis 如 InitRedis(ctx, WithRedis(rdb_single.Get()))
+func InitRedis(ctx context.Context, opts ...Option) (err error) {
+	r := NewRedisRegistry()
+	if err = r.Init(ctx, opts...); err != nil {
+		return err
+	}
+	defRegistry = r
+
+	return nil
+}
+
+func InitMemory(ctx context.Context, opts ...Option) (err error) {
+	r := NewMemoryRegistry()
+	if err = r.Init(ctx, opts...); err != nil {
+		return err
+	}
+	defRegistry = r
+
+	return nil
+}
+
+func Get() iface.IRegistry {
+	return defRegistry
+}
+
+func Register(ctx context.Context, ins *iface.ServiceInstance) error {
+	return defRegistry.Register(ctx, ins)
+}
+
+func Deregister(ctx context.Context, ins *iface.ServiceInstance) error {
+	return defRegistry.Deregister(ctx, ins)
+}
+
+func List(ctx context.Context, serverType int32) ([]*iface.ServiceInstance, error) {
+	return defRegistry.List(ctx, serverType)
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/iface"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	ins      iface.ServiceInstance
+	expireAt time.Time
+}
+
+// MemoryRegistry 进程内的服务注册, 用于测试和单进程部署
+type MemoryRegistry struct {
+	options *RegistryOption
+	keeper  *keeper
+
+	mu       sync.Mutex
+	services map[int32]map[uint64]*memoryEntry // serverType:id:entry
+}
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		options:  NewRegistryOption(),
+		keeper:   newKeeper(),
+		services: make(map[int32]map[uint64]*memoryEntry),
+	}
+}
+
+func (r *MemoryRegistry) Init(ctx context.Context, opts ...Option) (err error) {
+	for _, opt := range opts {
+		opt(r.options)
+	}
+
+	return nil
+}
+
+func (r *MemoryRegistry) Register(ctx context.Context, ins *iface.ServiceInstance) error {
+	return r.keeper.keep(ctx, instanceKey(ins), r.options.ttl/3, func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		entries, ok := r.services[ins.Type]
+		if !ok {
+			entries = make(map[uint64]*memoryEntry)
+			r.services[ins.Type] = entries
+		}
+		entries[ins.ID] = &memoryEntry{ins: *ins, expireAt: time.Now().Add(r.options.ttl)}
+
+		return nil
+	})
+}
+
+func (r *MemoryRegistry) Deregister(ctx context.Context, ins *iface.ServiceInstance) error {
+	r.keeper.stop(instanceKey(ins))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.services[ins.Type], ins.ID)
+	return nil
+}
+
+func (r *MemoryRegistry) List(ctx context.Context, serverType int32) ([]*iface.ServiceInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	list := make([]*iface.ServiceInstance, 0, len(r.services[serverType]))
+	for id, e := range r.services[serverType] {
+		if now.After(e.expireAt) {
+			delete(r.services[serverType], id)
+			continue
+		}
+		ins := e.ins
+		list = append(list, &ins)
+	}
+	sortInstances(list)
+
+	return list, nil
+}
+
+func (r *MemoryRegistry) Watch(ctx context.Context, serverType int32) (<-chan []*iface.ServiceInstance, error) {
+	return watch(ctx, r.options.watchInterval, func(ctx context.Context) ([]*iface.ServiceInstance, error) {
+		return r.List(ctx, serverType)
+	})
+}
@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"github.com/redis/go-redis/v9"
+	"github.com/v587-zyf/gc/enums"
+	"time"
+)
+
+type RegistryOption struct {
+	ttl           time.Duration
+	watchInterval time.Duration
+
+	client redis.UniversalClient
+	key    string
+}
+
+type Option func(opts *RegistryOption)
+
+func NewRegistryOption() *RegistryOption {
+	o := &RegistryOption{
+		ttl:           enums.REGISTRY_TTL,
+		watchInterval: enums.REGISTRY_WATCH_INTERVAL,
+		key:           enums.RDB_KEY_SER_REGISTRY,
+	}
+
+	return o
+}
+
+func WithTTL(ttl time.Duration) Option {
+	return func(opts *RegistryOption) {
+		if ttl > 0 {
+			opts.ttl = ttl
+		}
+	}
+}
+
+func WithWatchInterval(d time.Duration) Option {
+	return func(opts *RegistryOption) {
+		if d > 0 {
+			opts.watchInterval = d
+		}
+	}
+}
+
+// WithRedis RedisRegistry 使用的连接, 如 rdb_single.Get() 或 rdb_cluster.Get()
+func WithRedis(client redis.UniversalClient) Option {
+	return func(opts *RegistryOption) {
+		opts.client = client
+	}
+}
+
+// WithKey RedisRegistry 的 key 前缀, 不同环境共用 redis 时区分
+func WithKey(key string) Option {
+	return func(opts *RegistryOption) {
+		if key != "" {
+			opts.key = key
+		}
+	}
+}
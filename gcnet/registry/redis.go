@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"strconv"
+	"time"
+)
+
+type redisRecord struct {
+	iface.ServiceInstance
+	ExpireAt int64 `json:"expire_at"` // 毫秒
+}
+
+// delExpiredScript 仅当 field 仍是读取时的值才删除, 避免误删期间已续期的实例
+var delExpiredScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// RedisRegistry 每个服务器类型一个 hash, field 为实例 ID, 过期的实例在 List 时过滤并原子删除
+type RedisRegistry struct {
+	options *RegistryOption
+	keeper  *keeper
+}
+
+func NewRedisRegistry() *RedisRegistry {
+	return &RedisRegistry{
+		options: NewRegistryOption(),
+		keeper:  newKeeper(),
+	}
+}
+
+func (r *RedisRegistry) Init(ctx context.Context, opts ...Option) (err error) {
+	for _, opt := range opts {
+		opt(r.options)
+	}
+	if r.options.client == nil {
+		return errcode.ERR_CONFIG_NIL
+	}
+
+	return nil
+}
+
+func (r *RedisRegistry) hashKey(serverType int32) string {
+	return fmt.Sprintf("%s:%d", r.options.key, serverType)
+}
+
+func (r *RedisRegistry) Register(ctx context.Context, ins *iface.ServiceInstance) error {
+	return r.keeper.keep(ctx, instanceKey(ins), r.options.ttl/3, func(ctx context.Context) error {
+		data, err := json.Marshal(&redisRecord{
+			ServiceInstance: *ins,
+			ExpireAt:        time.Now().Add(r.options.ttl).UnixMilli(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.options.client.HSet(ctx, r.hashKey(ins.Type), strconv.FormatUint(ins.ID, 10), data).Err()
+	})
+}
+
+func (r *RedisRegistry) Deregister(ctx context.Context, ins *iface.ServiceInstance) error {
+	r.keeper.stop(instanceKey(ins))
+
+	return r.options.client.HDel(ctx, r.hashKey(ins.Type), strconv.FormatUint(ins.ID, 10)).Err()
+}
+
+func (r *RedisRegistry) List(ctx context.Context, serverType int32) ([]*iface.ServiceInstance, error) {
+	key := r.hashKey(serverType)
+	all, err := r.options.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	list := make([]*iface.ServiceInstance, 0, len(all))
+	for field, v := range all {
+		var rec redisRecord
+		if err = json.Unmarshal([]byte(v), &rec); err != nil || rec.ExpireAt < now {
+			delExpiredScript.Run(ctx, r.options.client, []string{key}, field, v)
+			continue
+		}
+		list = append(list, &rec.ServiceInstance)
+	}
+	sortInstances(list)
+
+	return list, nil
+}
+
+func (r *RedisRegistry) Watch(ctx context.Context, serverType int32) (<-chan []*iface.ServiceInstance, error) {
+	return watch(ctx, r.options.watchInterval, func(ctx context.Context) ([]*iface.ServiceInstance, error) {
+		return r.List(ctx, serverType)
+	})
+}
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"kernel/tools"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+func instanceKey(ins *iface.ServiceInstance) string {
+	return fmt.Sprintf("%d:%d", ins.Type, ins.ID)
+}
+
+func sortInstances(list []*iface.ServiceInstance) {
+	slices.SortFunc(list, func(a, b *iface.ServiceInstance) int {
+		switch {
+		case a.ID < b.ID:
+			return -1
+		case a.ID > b.ID:
+			return 1
+		}
+		return 0
+	})
+}
+
+func equalInstances(a, b []*iface.ServiceInstance) bool {
+	return slices.EqualFunc(a, b, func(x, y *iface.ServiceInstance) bool {
+		return x.Type == y.Type && x.ID == y.ID && x.Addr == y.Addr && maps.Equal(x.Meta, y.Meta)
+	})
+}
+
+// keeper 管理注册实例的续约协程
+type keeper struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newKeeper() *keeper {
+	return &keeper{cancels: make(map[string]context.CancelFunc)}
+}
+
+// keep 立即执行一次 put, 之后每 interval 执行一次, 直到 ctx 结束或 stop
+func (k *keeper) keep(ctx context.Context, key string, interval time.Duration, put func(ctx context.Context) error) error {
+	if err := put(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	k.mu.Lock()
+	if old, ok := k.cancels[key]; ok {
+		old()
+	}
+	k.cancels[key] = cancel
+	k.mu.Unlock()
+
+	go tools.GoSafe("registry keepalive", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := put(ctx); err != nil && ctx.Err() == nil {
+					log.Warn("registry keepalive err", zap.String("key", key), zap.Error(err))
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func (k *keeper) stop(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if cancel, ok := k.cancels[key]; ok {
+		cancel()
+		delete(k.cancels, key)
+	}
+}
+
+// watch 每 interval 调用一次 list, 列表变化时发送
+func watch(ctx context.Context, interval time.Duration, list func(ctx context.Context) ([]*iface.ServiceInstance, error)) (<-chan []*iface.ServiceInstance, error) {
+	cur, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []*iface.ServiceInstance, 1)
+	ch <- cur
+
+	go tools.GoSafe("registry watch", func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				next, err := list(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Warn("registry watch err", zap.Error(err))
+					}
+					continue
+				}
+				if equalInstances(cur, next) {
+					continue
+				}
+				cur = next
+
+				select {
+				case ch <- next:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+
+	return ch, nil
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMemoryRegistry(t *testing.T) {
+	var as = assert.New(t)
+
+	r := NewMemoryRegistry()
+	as.NoError(r.Init(context.Background(), WithTTL(90*time.Millisecond), WithWatchInterval(10*time.Millisecond)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := r.Watch(ctx, enums.SERVER_GAME)
+	as.NoError(err)
+	as.Empty(<-ch)
+
+	a := &iface.ServiceInstance{Type: enums.SERVER_GAME, ID: 2, Addr: "a"}
+	b := &iface.ServiceInstance{Type: enums.SERVER_GAME, ID: 1, Addr: "b"}
+	as.NoError(r.Register(ctx, a))
+	bCtx, bCancel := context.WithCancel(ctx)
+	as.NoError(r.Register(bCtx, b))
+
+	list := <-ch
+	as.Len(list, 2)
+	as.Equal(uint64(1), list[0].ID)
+
+	// 续约中的实例不会过期, 停止续约后过期
+	bCancel()
+	time.Sleep(200 * time.Millisecond)
+	list, err = r.List(ctx, enums.SERVER_GAME)
+	as.NoError(err)
+	as.Equal([]*iface.ServiceInstance{a}, list)
+
+	as.NoError(r.Deregister(ctx, a))
+	list, _ = r.List(ctx, enums.SERVER_GAME)
+	as.Empty(list)
+}
+
+func TestResolver(t *testing.T) {
+	var as = assert.New(t)
+
+	r := NewMemoryRegistry()
+	as.NoError(r.Init(context.Background(), WithWatchInterval(10*time.Millisecond)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 1; i <= 3; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		as.NoError(err)
+		s := grpc.NewServer()
+		grpc_health_v1.RegisterHealthServer(s, health.NewServer())
+		go s.Serve(lis)
+		defer s.Stop()
+
+		as.NoError(r.Register(ctx, &iface.ServiceInstance{Type: enums.SERVER_GAME, ID: uint64(i), Addr: lis.Addr().String()}))
+	}
+
+	// pick 返回处理请求的实例地址
+	pick := func(cc *grpc.ClientConn, ctx context.Context) string {
+		var p peer.Peer
+		_, err := grpc_health_v1.NewHealthClient(cc).Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Peer(&p), grpc.WaitForReady(true))
+		as.NoError(err)
+		return p.Addr.String()
+	}
+	dial := func(balancer string) *grpc.ClientConn {
+		cc, err := grpc.NewClient(fmt.Sprintf("%s:///%d", enums.REGISTRY_SCHEME, enums.SERVER_GAME),
+			grpc.WithResolvers(NewResolverBuilder(r)),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{"%s":{}}]}`, balancer)))
+		as.NoError(err)
+		return cc
+	}
+
+	rr := dial(enums.BALANCER_ROUND_ROBIN)
+	defer rr.Close()
+	as.Eventually(func() bool {
+		seen := make(map[string]struct{})
+		for i := 0; i < 6; i++ {
+			seen[pick(rr, ctx)] = struct{}{}
+		}
+		return len(seen) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	ch := dial(enums.BALANCER_CONSISTENT_HASH)
+	defer ch.Close()
+	as.Eventually(func() bool {
+		seen := make(map[string]struct{})
+		for i := 0; i < 6; i++ {
+			seen[pick(ch, ctx)] = struct{}{}
+		}
+		return len(seen) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	users := make(map[string]struct{})
+	for userID := uint64(1); userID <= 50; userID++ {
+		hashCtx := NewHashCtx(ctx, userID)
+		addr := pick(ch, hashCtx)
+		as.Equal(addr, pick(ch, hashCtx))
+		users[addr] = struct{}{}
+	}
+	as.Len(users, 3)
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+	"kernel/tools"
+	"strconv"
+	"strings"
+)
+
+type instanceIDKey struct{}
+
+// resolverBuilder 解析 gc:///<serverType>, 地址列表随注册中心变化
+type resolverBuilder struct {
+	registry iface.IRegistry
+}
+
+// NewResolverBuilder 用于 grpc.WithResolvers, 如 grpc.NewClient("gc:///3", grpc.WithResolvers(NewResolverBuilder(reg)))
+func NewResolverBuilder(reg iface.IRegistry) resolver.Builder {
+	return &resolverBuilder{registry: reg}
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return enums.REGISTRY_SCHEME
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	serverType, err := strconv.ParseInt(strings.TrimPrefix(target.Endpoint(), "/"), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := b.registry.Watch(ctx, int32(serverType))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go tools.GoSafe("registry resolver", func() {
+		for list := range ch {
+			addrs := make([]resolver.Address, 0, len(list))
+			for _, ins := range list {
+				addrs = append(addrs, resolver.Address{
+					Addr:       ins.Addr,
+					Attributes: attributes.New(instanceIDKey{}, ins.ID),
+				})
+			}
+			cc.UpdateState(resolver.State{Addresses: addrs})
+		}
+	})
+
+	return &registryResolver{cancel: cancel}, nil
+}
+
+type registryResolver struct {
+	cancel context.CancelFunc
+}
+
+// ResolveNow 列表由 Watch 推送, 无需主动解析
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+}
@@ -0,0 +1,154 @@
+package session_mgr
+
+import (
+	"github.com/v587-zyf/gc/iface"
+)
+
+func (s *SessionMgr) GroupJoin(group string, ss iface.ISession) {
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+
+	members, ok := s.groups[group]
+	if !ok {
+		members = make(map[iface.ISession]struct{})
+		s.groups[group] = members
+	}
+	members[ss] = struct{}{}
+
+	joined, ok := s.sessionGroups[ss]
+	if !ok {
+		joined = make(map[string]struct{})
+		s.sessionGroups[ss] = joined
+	}
+	joined[group] = struct{}{}
+}
+
+func (s *SessionMgr) GroupLeave(group string, ss iface.ISession) {
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+
+	s.groupLeave(group, ss)
+}
+
+// GroupLeaveAll 离开所有分组, 断开连接时自动调用
+func (s *SessionMgr) GroupLeaveAll(ss iface.ISession) {
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+
+	for group := range s.sessionGroups[ss] {
+		s.groupLeave(group, ss)
+	}
+}
+
+func (s *SessionMgr) groupLeave(group string, ss iface.ISession) {
+	if members, ok := s.groups[group]; ok {
+		delete(members, ss)
+		if len(members) == 0 {
+			delete(s.groups, group)
+		}
+	}
+
+	if joined, ok := s.sessionGroups[ss]; ok {
+		delete(joined, group)
+		if len(joined) == 0 {
+			delete(s.sessionGroups, ss)
+		}
+	}
+}
+
+func (s *SessionMgr) GroupList() []string {
+	s.groupMu.RLock()
+	defer s.groupMu.RUnlock()
+
+	groups := make([]string, 0, len(s.groups))
+	for group := range s.groups {
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func (s *SessionMgr) GroupLen(group string) int {
+	s.groupMu.RLock()
+	defer s.groupMu.RUnlock()
+
+	return len(s.groups[group])
+}
+
+func (s *SessionMgr) GroupMembers(group string) []iface.ISession {
+	s.groupMu.RLock()
+	defer s.groupMu.RUnlock()
+
+	members := make([]iface.ISession, 0, len(s.groups[group]))
+	for ss := range s.groups[group] {
+		members = append(members, ss)
+	}
+
+	return members
+}
+
+func (s *SessionMgr) SessionGroups(ss iface.ISession) []string {
+	s.groupMu.RLock()
+	defer s.groupMu.RUnlock()
+
+	groups := make([]string, 0, len(s.sessionGroups[ss]))
+	for group := range s.sessionGroups[ss] {
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func (s *SessionMgr) InGroup(group string, ss iface.ISession) bool {
+	s.groupMu.RLock()
+	defer s.groupMu.RUnlock()
+
+	_, ok := s.groups[group][ss]
+	return ok
+}
+
+// BroadcastGroup 向分组内所有会话发送同一份数据, exclude 中的会话除外
+func (s *SessionMgr) BroadcastGroup(group string, data []byte, exclude ...iface.ISession) {
+	broadcast(s.GroupMembers(group), data, exclude)
+}
+
+// BroadcastAll 向所有连接发送同一份数据, exclude 中的会话除外
+func (s *SessionMgr) BroadcastAll(data []byte, exclude ...iface.ISession) {
+	members := make([]iface.ISession, 0, s.AllLength())
+	s.AllRange(func(ss iface.ISession) bool {
+		members = append(members, ss)
+		return true
+	})
+
+	broadcast(members, data, exclude)
+}
+
+// BroadcastOnline 向所有已登录的会话发送同一份数据, exclude 中的会话除外
+func (s *SessionMgr) BroadcastOnline(data []byte, exclude ...iface.ISession) {
+	s.onlineMu.Lock()
+	members := make([]iface.ISession, 0, len(s.onlineDevices))
+	for _, devices := range s.onlineDevices {
+		for _, ss := range devices {
+			members = append(members, ss)
+		}
+	}
+	s.onlineMu.Unlock()
+
+	broadcast(members, data, exclude)
+}
+
+func broadcast(members []iface.ISession, data []byte, exclude []iface.ISession) {
+	fn := func(args ...any) ([]byte, error) {
+		return data, nil
+	}
+
+LOOP:
+	for _, ss := range members {
+		for _, e := range exclude {
+			if ss == e {
+				continue LOOP
+			}
+		}
+		ss.SendMsg(fn)
+	}
+}
@@ -0,0 +1,77 @@
+package session_mgr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/iface"
+	"testing"
+)
+
+type testSession struct {
+	iface.ISession
+	id     uint64
+	device string
+	sent   [][]byte
+	closed chan struct{}
+}
+
+func newTestSession(id uint64, device string) *testSession {
+	return &testSession{id: id, device: device, closed: make(chan struct{})}
+}
+
+func (s *testSession) GetID() uint64 { return s.id }
+
+func (s *testSession) Get(key string) (any, bool) {
+	if key == DEVICE_KEY {
+		return s.device, true
+	}
+	return nil, false
+}
+
+func (s *testSession) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func (s *testSession) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	data, err := fn(args...)
+	if err != nil {
+		return err
+	}
+	s.sent = append(s.sent, data)
+	return nil
+}
+
+func TestGroup(t *testing.T) {
+	var as = assert.New(t)
+
+	mgr := NewSessionMgr()
+	a, b, c := newTestSession(1, ""), newTestSession(2, ""), newTestSession(3, "")
+	for _, ss := range []*testSession{a, b, c} {
+		mgr.AllAdd(ss)
+	}
+
+	mgr.GroupJoin("room", a)
+	mgr.GroupJoin("room", b)
+	mgr.GroupJoin("guild", a)
+	as.Equal(2, mgr.GroupLen("room"))
+	as.ElementsMatch([]string{"room", "guild"}, mgr.GroupList())
+	as.ElementsMatch([]string{"room", "guild"}, mgr.SessionGroups(a))
+
+	mgr.BroadcastGroup("room", []byte("r"), b)
+	as.Len(a.sent, 1)
+	as.Len(b.sent, 0)
+	as.Len(c.sent, 0)
+
+	mgr.BroadcastAll([]byte("all"))
+	as.Len(a.sent, 2)
+	as.Len(b.sent, 1)
+	as.Len(c.sent, 1)
+
+	mgr.Disconnect(a)
+	as.False(mgr.InGroup("room", a))
+	as.Equal(1, mgr.GroupLen("room"))
+	as.ElementsMatch([]string{"room"}, mgr.GroupList())
+
+	mgr.GroupLeave("room", b)
+	as.Empty(mgr.GroupList())
+}
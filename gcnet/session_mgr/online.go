@@ -0,0 +1,145 @@
+package session_mgr
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"sync/atomic"
+)
+
+func (s *SessionMgr) device(ss iface.ISession) string {
+	if s.options.loginPolicy != LOGIN_POLICY_MULTI_DEVICE {
+		return ""
+	}
+	if v, ok := ss.Get(DEVICE_KEY); ok {
+		if device, ok := v.(string); ok {
+			return device
+		}
+	}
+	return ""
+}
+
+// onlineAdd 返回被顶替的旧会话
+func (s *SessionMgr) onlineAdd(userID uint64, ss iface.ISession) (old iface.ISession) {
+	s.onlineMu.Lock()
+	defer s.onlineMu.Unlock()
+
+	devices, ok := s.onlineDevices[userID]
+	if !ok {
+		devices = make(map[string]iface.ISession)
+		s.onlineDevices[userID] = devices
+		atomic.AddInt64(&s.onlineClientN, 1)
+	}
+
+	device := s.device(ss)
+	if old = devices[device]; old == ss {
+		old = nil
+	}
+	devices[device] = ss
+	s.onlineClients.Store(userID, ss)
+
+	return
+}
+
+// onlineDel 只移除 ss 自己, 避免旧连接断开时删掉新连接
+func (s *SessionMgr) onlineDel(userID uint64, ss iface.ISession) {
+	s.onlineMu.Lock()
+	defer s.onlineMu.Unlock()
+
+	devices, ok := s.onlineDevices[userID]
+	if !ok {
+		return
+	}
+	for device, v := range devices {
+		if v == ss {
+			delete(devices, device)
+		}
+	}
+
+	if len(devices) == 0 {
+		delete(s.onlineDevices, userID)
+		s.onlineClients.Delete(userID)
+		atomic.AddInt64(&s.onlineClientN, -1)
+		return
+	}
+	if v, ok := s.onlineClients.Load(userID); ok && v.(iface.ISession) == ss {
+		for _, other := range devices {
+			s.onlineClients.Store(userID, other)
+			break
+		}
+	}
+}
+
+// onlineDelAll 移除用户的所有会话并返回
+func (s *SessionMgr) onlineDelAll(userID uint64) (sessions []iface.ISession) {
+	s.onlineMu.Lock()
+	defer s.onlineMu.Unlock()
+
+	devices, ok := s.onlineDevices[userID]
+	if !ok {
+		return
+	}
+	for _, ss := range devices {
+		sessions = append(sessions, ss)
+	}
+
+	delete(s.onlineDevices, userID)
+	s.onlineClients.Delete(userID)
+	atomic.AddInt64(&s.onlineClientN, -1)
+
+	return
+}
+
+// OnlineGetAll 返回用户所有在线会话, 多端登录时可能有多个
+func (s *SessionMgr) OnlineGetAll(userID uint64) []iface.ISession {
+	s.onlineMu.Lock()
+	defer s.onlineMu.Unlock()
+
+	sessions := make([]iface.ISession, 0, len(s.onlineDevices[userID]))
+	for _, ss := range s.onlineDevices[userID] {
+		sessions = append(sessions, ss)
+	}
+
+	return sessions
+}
+
+// Kick 将用户的所有会话踢下线
+func (s *SessionMgr) Kick(userID uint64, reason errcode.ErrCode) {
+	for _, ss := range s.onlineDelAll(userID) {
+		s.kick(ss, reason)
+	}
+}
+
+// KickSession 将指定会话踢下线
+func (s *SessionMgr) KickSession(ss iface.ISession, reason errcode.ErrCode) {
+	if ss.GetID() != 0 {
+		s.onlineDel(ss.GetID(), ss)
+	}
+	s.kick(ss, reason)
+}
+
+// kick 异步发送踢下线消息并关闭会话, 避免在管理器循环中阻塞
+func (s *SessionMgr) kick(ss iface.ISession, reason errcode.ErrCode) {
+	var data []byte
+	if s.options.kickMsgFn != nil {
+		data = s.options.kickMsgFn(ss.GetID(), reason)
+	}
+
+	go func() {
+		if len(data) > 0 {
+			ss.SendMsg(func(args ...any) ([]byte, error) {
+				return data, nil
+			})
+		}
+
+		if g, ok := ss.(iface.ISessionShutdown); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), enums.CONN_WRITE_WAIT_TIME)
+			defer cancel()
+
+			g.Shutdown(ctx)
+		} else {
+			ss.Close()
+		}
+	}()
+}
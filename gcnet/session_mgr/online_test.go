@@ -0,0 +1,114 @@
+package session_mgr
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"testing"
+	"time"
+)
+
+func isClosed(ss *testSession) bool {
+	select {
+	case <-ss.closed:
+		return true
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
+}
+
+func TestLoginPolicy(t *testing.T) {
+	var as = assert.New(t)
+
+	t.Run("kick old", func(t *testing.T) {
+		mgr := NewSessionMgr()
+		var reasons []errcode.ErrCode
+		mgr.Init(context.Background(), WithKickMsgFn(func(userID uint64, reason errcode.ErrCode) []byte {
+			reasons = append(reasons, reason)
+			return []byte("kick")
+		}))
+
+		oldSS, newSS := newTestSession(1, ""), newTestSession(1, "")
+		mgr.AllAdd(oldSS)
+		mgr.AllAdd(newSS)
+		mgr.Login(oldSS)
+		mgr.Login(newSS)
+
+		as.True(isClosed(oldSS))
+		as.Equal([]errcode.ErrCode{errcode.ERR_NET_LOGIN_REPLACED}, reasons)
+		as.Equal(1, mgr.OnlineLen())
+
+		// 旧连接断开不影响新连接
+		mgr.Disconnect(oldSS)
+		ss, ok := mgr.IsOnline(1)
+		as.True(ok)
+		as.Equal(newSS, ss)
+		as.Equal(1, mgr.OnlineLen())
+
+		mgr.Kick(1, errcode.ERR_NET_KICKED)
+		as.True(isClosed(newSS))
+		as.Equal(0, mgr.OnlineLen())
+		mgr.Disconnect(newSS)
+		as.Equal(0, mgr.OnlineLen())
+		as.Equal(0, mgr.AllLength())
+	})
+
+	t.Run("reject new", func(t *testing.T) {
+		mgr := NewSessionMgr()
+		mgr.Init(context.Background(), WithLoginPolicy(LOGIN_POLICY_REJECT_NEW))
+
+		oldSS, newSS := newTestSession(1, ""), newTestSession(1, "")
+		mgr.AllAdd(oldSS)
+		mgr.AllAdd(newSS)
+		mgr.Login(oldSS)
+		mgr.Login(newSS)
+
+		as.True(isClosed(newSS))
+		as.False(isClosed(oldSS))
+		mgr.Disconnect(newSS)
+		ss, _ := mgr.IsOnline(1)
+		as.Equal(oldSS, ss)
+	})
+
+	t.Run("multi device", func(t *testing.T) {
+		mgr := NewSessionMgr()
+		mgr.Init(context.Background(), WithLoginPolicy(LOGIN_POLICY_MULTI_DEVICE))
+
+		pc, phone, phone2 := newTestSession(1, "pc"), newTestSession(1, "phone"), newTestSession(1, "phone")
+		for _, ss := range []*testSession{pc, phone, phone2} {
+			mgr.AllAdd(ss)
+			mgr.Login(ss)
+		}
+
+		as.True(isClosed(phone))
+		as.False(isClosed(pc))
+		as.Len(mgr.OnlineGetAll(1), 2)
+		as.Equal(1, mgr.OnlineLen())
+
+		mgr.Disconnect(pc)
+		as.Len(mgr.OnlineGetAll(1), 1)
+		ss, _ := mgr.IsOnline(1)
+		as.Equal(phone2, ss)
+	})
+}
+
+func TestShutdownFunc(t *testing.T) {
+	var as = assert.New(t)
+
+	mgr := NewSessionMgr()
+	mgr.Init(context.Background())
+
+	tcp, ws := newTestSession(1, ""), newTestSession(2, "")
+	mgr.AllAdd(tcp)
+	mgr.AllAdd(ws)
+
+	// 只关闭 filter 选中的会话, 其他服务器的会话不受影响
+	as.NoError(mgr.ShutdownFunc(context.Background(), []byte("bye"), func(ss iface.ISession) bool {
+		return ss == tcp
+	}))
+	as.True(isClosed(tcp))
+	as.Equal([][]byte{[]byte("bye")}, tcp.sent)
+	as.False(isClosed(ws))
+	as.Empty(ws.sent)
+}
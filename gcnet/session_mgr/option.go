@@ -0,0 +1,67 @@
+package session_mgr
+
+import (
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"time"
+)
+
+type LoginPolicy int
+
+const (
+	LOGIN_POLICY_KICK_OLD     LoginPolicy = iota // 踢掉旧连接
+	LOGIN_POLICY_REJECT_NEW                      // 拒绝新连接
+	LOGIN_POLICY_MULTI_DEVICE                    // 按设备号允许多端同时在线
+)
+
+// DEVICE_KEY 多端登录时通过 ss.Set(DEVICE_KEY, deviceID) 设置设备号
+const DEVICE_KEY = "device"
+
+// KickMsgFn 构造踢下线时发给客户端的消息
+type KickMsgFn func(userID uint64, reason errcode.ErrCode) []byte
+
+// TimeoutFn 心跳超时关闭会话前调用, 可用于记录日志或保存数据
+type TimeoutFn func(ss iface.ISession)
+
+type SessionMgrOption struct {
+	loginPolicy LoginPolicy
+	kickMsgFn   KickMsgFn
+
+	heartbeatInterval time.Duration
+	timeoutFn         TimeoutFn
+}
+
+type Option func(opts *SessionMgrOption)
+
+func NewSessionMgrOption() *SessionMgrOption {
+	o := &SessionMgrOption{
+		loginPolicy: LOGIN_POLICY_KICK_OLD,
+	}
+
+	return o
+}
+
+func WithLoginPolicy(policy LoginPolicy) Option {
+	return func(opts *SessionMgrOption) {
+		opts.loginPolicy = policy
+	}
+}
+
+func WithKickMsgFn(fn KickMsgFn) Option {
+	return func(opts *SessionMgrOption) {
+		opts.kickMsgFn = fn
+	}
+}
+
+// WithHeartbeatInterval 每隔 d 检查一次心跳超时, 0 表示不检查
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(opts *SessionMgrOption) {
+		opts.heartbeatInterval = d
+	}
+}
+
+func WithTimeoutFn(fn TimeoutFn) Option {
+	return func(opts *SessionMgrOption) {
+		opts.timeoutFn = fn
+	}
+}
@@ -0,0 +1,43 @@
+package session_mgr
+
+import (
+	"github.com/v587-zyf/gc/iface"
+)
+
+// RESUME_TOKEN_KEY 开启断线重连的会话在 Login 时通过 ss.Set(RESUME_TOKEN_KEY, token) 保存 token
+const RESUME_TOKEN_KEY = "resume_token"
+
+func resumeToken(ss iface.ISession) string {
+	if v, ok := ss.Get(RESUME_TOKEN_KEY); ok {
+		if token, ok := v.(string); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+func (s *SessionMgr) resumeAdd(ss iface.ISession) {
+	if token := resumeToken(ss); token != "" {
+		s.resumes.Store(token, ss)
+	}
+}
+
+func (s *SessionMgr) resumeDel(ss iface.ISession) {
+	if token := resumeToken(ss); token != "" {
+		s.resumes.CompareAndDelete(token, ss)
+	}
+}
+
+// Resume 根据 token 查找可重连的会话
+func (s *SessionMgr) Resume(token string) (ss iface.ISession, ok bool) {
+	if token == "" {
+		return
+	}
+
+	v, ok := s.resumes.Load(token)
+	if !ok {
+		return
+	}
+
+	return v.(iface.ISession), true
+}
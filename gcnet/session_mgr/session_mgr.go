@@ -0,0 +1,289 @@
+package session_mgr
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var sessionMgr *SessionMgr
+
+func init() {
+	sessionMgr = NewSessionMgr()
+}
+
+// SessionMgr 管理所有会话, tcp_server 和 ws_server 共用默认实例, 网关可按 userID 统一查找和推送
+type SessionMgr struct {
+	options *SessionMgrOption
+	started atomic.Bool
+
+	sweepMu       sync.Mutex
+	sweepSeq      uint64
+	sweepHolders  map[uint64]time.Duration
+	sweepInterval time.Duration
+	sweepCancel   context.CancelFunc
+
+	allClients sync.Map // iface.ISession:struct{}
+	allClientN int64
+
+	onlineClients sync.Map // uint64:iface.ISession
+	onlineClientN int64
+	onlineMu      sync.Mutex
+	onlineDevices map[uint64]map[string]iface.ISession // userID:device:ss
+
+	groupMu       sync.RWMutex
+	groups        map[string]map[iface.ISession]struct{} // group:ss
+	sessionGroups map[iface.ISession]map[string]struct{} // ss:group
+
+	resumes sync.Map // token:iface.ISession
+
+	RegisterCh   chan iface.ISession
+	LoginCh      chan iface.ISession
+	UnRegisterCh chan iface.ISession
+}
+
+func GetSessionMgr() *SessionMgr {
+	return sessionMgr
+}
+
+func Init(ctx context.Context, opts ...Option) error {
+	return sessionMgr.Init(ctx, opts...)
+}
+
+func NewSessionMgr() *SessionMgr {
+	s := &SessionMgr{
+		options: NewSessionMgrOption(),
+
+		RegisterCh:   make(chan iface.ISession, 512),
+		LoginCh:      make(chan iface.ISession, 512),
+		UnRegisterCh: make(chan iface.ISession, 512),
+
+		groups:        make(map[string]map[iface.ISession]struct{}),
+		sessionGroups: make(map[iface.ISession]map[string]struct{}),
+
+		onlineDevices: make(map[uint64]map[string]iface.ISession),
+
+		sweepHolders: make(map[uint64]time.Duration),
+	}
+
+	return s
+}
+
+// Init 可多次调用, 设置了心跳间隔时检查持续到 ctx 结束, 不影响其他调用方的检查
+func (s *SessionMgr) Init(ctx context.Context, opts ...Option) error {
+	s.sweepMu.Lock()
+	for _, opt := range opts {
+		opt(s.options)
+	}
+	interval := s.options.heartbeatInterval
+	s.sweepMu.Unlock()
+
+	if interval > 0 {
+		context.AfterFunc(ctx, s.Sweep(interval))
+	}
+
+	return nil
+}
+
+func (s *SessionMgr) AllLength() int {
+	return int(atomic.LoadInt64(&s.allClientN))
+}
+
+func (s *SessionMgr) IsConn(ss iface.ISession) (ok bool) {
+	_, ok = s.allClients.Load(ss)
+
+	return
+}
+
+func (s *SessionMgr) GetAll() (allSS map[iface.ISession]struct{}) {
+	allSS = make(map[iface.ISession]struct{})
+
+	s.AllRange(func(ss iface.ISession) (result bool) {
+		allSS[ss] = struct{}{}
+		return true
+	})
+
+	return
+}
+
+func (s *SessionMgr) AllRange(fn func(ss iface.ISession) (result bool)) {
+	s.allClients.Range(func(key, value any) bool {
+		ss := key.(iface.ISession)
+		result := fn(ss)
+		if !result {
+			return false
+		}
+		return true
+	})
+}
+
+func (s *SessionMgr) AllAdd(ss iface.ISession) {
+	s.allClients.Store(ss, struct{}{})
+	atomic.AddInt64(&s.allClientN, 1)
+}
+
+func (s *SessionMgr) AllDel(ss iface.ISession) {
+	if _, ok := s.allClients.LoadAndDelete(ss); ok {
+		atomic.AddInt64(&s.allClientN, -1)
+	}
+}
+
+func (s *SessionMgr) OnlineLen() int {
+	return int(atomic.LoadInt64(&s.onlineClientN))
+}
+
+// OnlineAdd 直接登记在线, 不执行登录策略
+func (s *SessionMgr) OnlineAdd(userID uint64, ss iface.ISession) {
+	s.onlineAdd(userID, ss)
+}
+
+func (s *SessionMgr) OnlineDel(userID uint64) {
+	s.onlineDelAll(userID)
+}
+
+func (s *SessionMgr) OnlineGetOne(userID uint64) (ss iface.ISession) {
+	if v, ok := s.onlineClients.Load(userID); ok {
+		ss = v.(iface.ISession)
+	}
+
+	return
+}
+
+func (s *SessionMgr) OnlineOnce(userID uint64, fn func(ss iface.ISession)) {
+	cli := s.OnlineGetOne(userID)
+	if cli == nil {
+		return
+	}
+
+	fn(cli)
+}
+
+func (s *SessionMgr) OnlineRange(fn func(userID uint64, ss iface.ISession)) {
+	s.onlineClients.Range(func(key, value any) bool {
+		userID := key.(uint64)
+		ss := value.(iface.ISession)
+		fn(userID, ss)
+		return true
+	})
+
+	return
+}
+
+func (s *SessionMgr) IsOnline(userID uint64) (ss iface.ISession, ok bool) {
+	if val, ok := s.onlineClients.Load(userID); ok {
+		ss = val.(iface.ISession)
+		return ss, true
+	}
+	return
+}
+
+func (s *SessionMgr) Login(ss iface.ISession) {
+	userID := ss.GetID()
+	if !s.IsConn(ss) || userID == 0 {
+		return
+	}
+
+	if s.options.loginPolicy == LOGIN_POLICY_REJECT_NEW {
+		if old, ok := s.IsOnline(userID); ok && old != ss {
+			s.kick(ss, errcode.ERR_NET_LOGIN_REJECTED)
+			return
+		}
+	}
+
+	if old := s.onlineAdd(userID, ss); old != nil {
+		s.kick(old, errcode.ERR_NET_LOGIN_REPLACED)
+	}
+	s.resumeAdd(ss)
+}
+func (s *SessionMgr) Disconnect(ss iface.ISession) {
+	s.GroupLeaveAll(ss)
+	s.resumeDel(ss)
+
+	if ss.GetID() != 0 {
+		s.onlineDel(ss.GetID(), ss)
+	}
+
+	s.AllDel(ss)
+}
+
+// Start 处理注册、登录和断开, 多个服务器共用时只有第一次调用生效
+func (s *SessionMgr) Start() {
+	if !s.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	for {
+		select {
+		case ss := <-s.RegisterCh:
+			s.AllAdd(ss)
+		case ss := <-s.LoginCh:
+			s.Login(ss)
+		case ss := <-s.UnRegisterCh:
+			s.Disconnect(ss)
+		}
+	}
+}
+
+func (s *SessionMgr) ClearTimeout() {
+	currentTime := time.Now()
+
+	allClients := s.GetAll()
+	for session := range allClients {
+		var fn = func(args ...any) bool {
+			return session.IsHeartbeatTimeout(currentTime)
+		}
+		if session.CheckSomething(fn) {
+			if s.options.timeoutFn != nil {
+				s.options.timeoutFn(session)
+			}
+			session.Close()
+		}
+	}
+}
+
+// Shutdown 向所有会话发送 closeMsg(可为空)并等待其发送完队列中的消息后关闭
+func (s *SessionMgr) Shutdown(ctx context.Context, closeMsg []byte) error {
+	return s.ShutdownFunc(ctx, closeMsg, nil)
+}
+
+// ShutdownFunc 同 Shutdown, 只关闭 filter 返回 true 的会话, 多个服务器共用时各自关闭自己的会话
+func (s *SessionMgr) ShutdownFunc(ctx context.Context, closeMsg []byte, filter func(ss iface.ISession) bool) error {
+	var wg sync.WaitGroup
+	for ss := range s.GetAll() {
+		if filter != nil && !filter(ss) {
+			continue
+		}
+		if len(closeMsg) > 0 {
+			ss.SendMsg(func(args ...any) ([]byte, error) {
+				return closeMsg, nil
+			})
+		}
+
+		wg.Add(1)
+		go func(ss iface.ISession) {
+			defer wg.Done()
+
+			if g, ok := ss.(iface.ISessionShutdown); ok {
+				g.Shutdown(ctx)
+			} else {
+				ss.Close()
+			}
+		}(ss)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+package session_mgr
+
+import (
+	"context"
+	"kernel/tools"
+	"sync"
+	"time"
+)
+
+// Sweep 定时关闭心跳超时的会话, 不再需要时调用返回的 release
+// 多个服务器共用时按最小的间隔检查, 所有调用方都 release 后才停止
+func (s *SessionMgr) Sweep(interval time.Duration) (release func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	s.sweepMu.Lock()
+	s.sweepSeq++
+	id := s.sweepSeq
+	s.sweepHolders[id] = interval
+	s.resweep()
+	s.sweepMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.sweepMu.Lock()
+			delete(s.sweepHolders, id)
+			s.resweep()
+			s.sweepMu.Unlock()
+		})
+	}
+}
+
+// resweep 按当前最小间隔重启检查协程, 需持有 sweepMu
+func (s *SessionMgr) resweep() {
+	var interval time.Duration
+	for _, d := range s.sweepHolders {
+		if interval == 0 || d < interval {
+			interval = d
+		}
+	}
+	if interval == s.sweepInterval {
+		return
+	}
+
+	if s.sweepCancel != nil {
+		s.sweepCancel()
+		s.sweepCancel = nil
+	}
+	s.sweepInterval = interval
+	if interval == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.sweepCancel = cancel
+	go tools.GoSafe("session_mgr heartbeat sweeper", func() {
+		s.sweep(ctx, interval)
+	})
+}
+
+// sweep 定时关闭心跳超时的会话
+func (s *SessionMgr) sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.ClearTimeout()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package session_mgr
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSweep(t *testing.T) {
+	var as = assert.New(t)
+
+	interval := func(mgr *SessionMgr) time.Duration {
+		mgr.sweepMu.Lock()
+		defer mgr.sweepMu.Unlock()
+		return mgr.sweepInterval
+	}
+
+	mgr := NewSessionMgr()
+	release1 := mgr.Sweep(time.Second)
+	release2 := mgr.Sweep(100 * time.Millisecond)
+	as.Equal(100*time.Millisecond, interval(mgr))
+
+	// 一个服务器关闭后另一个的检查继续生效
+	release2()
+	release2()
+	as.Equal(time.Second, interval(mgr))
+	release1()
+	as.Equal(time.Duration(0), interval(mgr))
+
+	// 多次 Init 互不取消
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	as.NoError(mgr.Init(ctx1, WithHeartbeatInterval(time.Second)))
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	as.NoError(mgr.Init(ctx2))
+	cancel1()
+	as.Eventually(func() bool {
+		mgr.sweepMu.Lock()
+		defer mgr.sweepMu.Unlock()
+		return len(mgr.sweepHolders) == 1
+	}, time.Second, 10*time.Millisecond)
+	as.Equal(time.Second, interval(mgr))
+}
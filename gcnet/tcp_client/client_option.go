@@ -0,0 +1,132 @@
+package tcp_client
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"net"
+	"time"
+)
+
+// HeartbeatFn 构造定时发送给服务器的心跳消息
+type HeartbeatFn func() ([]byte, error)
+
+// DialFn 建立连接, 用于替换默认的 tcp 连接, 如 kcp_server.Dial
+type DialFn func(ctx context.Context, addr string, timeout time.Duration) (net.Conn, error)
+
+type ClientOption struct {
+	addr        string
+	dialTimeout time.Duration
+	dialFn      DialFn
+
+	method iface.ISessionMethod
+	codec  iface.ICodec
+
+	writeBatch int
+	cipher     bool
+
+	reconnect    bool
+	reconnectMin time.Duration
+	reconnectMax time.Duration
+
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	heartbeatFn       HeartbeatFn
+}
+
+type Option func(opts *ClientOption)
+
+func NewClientOption() *ClientOption {
+	o := &ClientOption{
+		dialTimeout: enums.CONN_DIAL_TIMEOUT,
+		codec:       codec.Get(),
+		writeBatch:  enums.SESSION_WRITE_BATCH,
+
+		reconnectMin: enums.RECONNECT_MIN_INTERVAL,
+		reconnectMax: enums.RECONNECT_MAX_INTERVAL,
+
+		heartbeatTimeout: enums.HEARTBEAT_TIMEOUT,
+	}
+
+	return o
+}
+
+func WithAddr(addr string) Option {
+	return func(opts *ClientOption) {
+		opts.addr = addr
+	}
+}
+
+func WithDialTimeout(d time.Duration) Option {
+	return func(opts *ClientOption) {
+		opts.dialTimeout = d
+	}
+}
+
+func WithMethod(m iface.ISessionMethod) Option {
+	return func(opts *ClientOption) {
+		opts.method = m
+	}
+}
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *ClientOption) {
+		if c != nil {
+			opts.codec = c
+		}
+	}
+}
+
+// WithReconnect 断线后自动重连, 重连间隔从 minInterval 开始翻倍, 最大为 maxInterval
+func WithReconnect(minInterval, maxInterval time.Duration) Option {
+	return func(opts *ClientOption) {
+		opts.reconnect = true
+		if minInterval > 0 {
+			opts.reconnectMin = minInterval
+		}
+		if maxInterval >= opts.reconnectMin {
+			opts.reconnectMax = maxInterval
+		}
+	}
+}
+
+// WithHeartbeat 每隔 interval 发送一次 fn 构造的心跳消息
+func WithHeartbeat(interval time.Duration, fn HeartbeatFn) Option {
+	return func(opts *ClientOption) {
+		opts.heartbeatInterval = interval
+		opts.heartbeatFn = fn
+	}
+}
+
+// WithHeartbeatTimeout 超过 d 未收到服务器消息视为断线
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(opts *ClientOption) {
+		if d > 0 {
+			opts.heartbeatTimeout = d
+		}
+	}
+}
+
+// WithWriteBatch 发送时最多合并 n 帧为一次写入, 1 表示不合并
+func WithWriteBatch(n int) Option {
+	return func(opts *ClientOption) {
+		if n > 0 {
+			opts.writeBatch = n
+		}
+	}
+}
+
+// WithCipher 连接后先完成 frame_cipher 握手, 用于开启了加密的服务器
+func WithCipher() Option {
+	return func(opts *ClientOption) {
+		opts.cipher = true
+	}
+}
+
+// WithDialFn 自定义建立连接的方式
+func WithDialFn(fn DialFn) Option {
+	return func(opts *ClientOption) {
+		opts.dialFn = fn
+	}
+}
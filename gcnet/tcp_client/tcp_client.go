@@ -0,0 +1,388 @@
+package tcp_client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/tcp_session"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"kernel/tools"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TcpClient 连接 tcp_server 的客户端, 实现 iface.ITcpSession, 可直接复用服务器的 Hooks 和 handler
+type TcpClient struct {
+	options *ClientOption
+
+	id uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	hooks *tcp_session.Hooks
+	cache sync.Map
+
+	connMu sync.RWMutex
+	conn   net.Conn
+	cipher *frame_cipher.Cipher
+
+	outChan chan []byte
+	isClose bool
+	closeMu sync.Mutex
+	closeCh chan struct{}
+
+	heartbeatTime atomic.Int64
+}
+
+func NewTcpClient() *TcpClient {
+	c := &TcpClient{
+		options: NewClientOption(),
+
+		hooks: tcp_session.NewHooks(),
+
+		outChan: make(chan []byte, 1024),
+		closeCh: make(chan struct{}),
+	}
+
+	return c
+}
+
+// Init 连接服务器, 首次连接失败直接返回错误
+func (c *TcpClient) Init(ctx context.Context, opts ...Option) (err error) {
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	for _, opt := range opts {
+		opt(c.options)
+	}
+	if c.options.method != nil {
+		c.hooks.OnMethod(c.options.method)
+	}
+
+	if c.conn, c.cipher, err = c.dial(); err != nil {
+		log.Error("tcp_client dial err", zap.String("addr", c.options.addr), zap.Error(err))
+		return
+	}
+	c.Heartbeat()
+
+	return nil
+}
+
+func (c *TcpClient) dial() (net.Conn, *frame_cipher.Cipher, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.options.dialFn != nil {
+		conn, err = c.options.dialFn(c.ctx, c.options.addr, c.options.dialTimeout)
+	} else {
+		d := net.Dialer{Timeout: c.options.dialTimeout}
+		conn, err = d.DialContext(c.ctx, "tcp", c.options.addr)
+	}
+	if err != nil || !c.options.cipher {
+		return conn, nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(c.options.dialTimeout))
+	cph, err := frame_cipher.ClientHandshake(frame_cipher.NewStreamConn(conn, c.options.codec), c.options.codec)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return conn, cph, nil
+}
+
+func (c *TcpClient) Start() {
+	go tools.GoSafe("tcp_client run", func() {
+		c.run()
+	})
+}
+
+func (c *TcpClient) Stop() {
+	c.Close()
+}
+
+// Done 客户端关闭后返回的 chan 会被关闭
+func (c *TcpClient) Done() <-chan struct{} {
+	return c.closeCh
+}
+
+func (c *TcpClient) Hooks() *tcp_session.Hooks {
+	return c.hooks
+}
+
+func (c *TcpClient) Set(key string, value any) {
+	c.cache.Store(key, value)
+}
+func (c *TcpClient) Get(key string) (any, bool) {
+	return c.cache.Load(key)
+}
+func (c *TcpClient) Remove(key string) {
+	c.cache.Delete(key)
+}
+
+func (c *TcpClient) GetID() uint64 {
+	return c.id
+}
+func (c *TcpClient) SetID(id uint64) {
+	c.id = id
+}
+
+func (c *TcpClient) Close() error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+
+	if !c.isClose {
+		c.isClose = true
+
+		c.cancel()
+		if conn := c.GetConn(); conn != nil {
+			conn.Close()
+		}
+		close(c.closeCh)
+	}
+
+	return nil
+}
+
+func (c *TcpClient) GetConn() net.Conn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+
+	return c.conn
+}
+
+func (c *TcpClient) GetCodec() iface.ICodec {
+	return c.options.codec
+}
+
+func (c *TcpClient) GetCtx() context.Context {
+	return c.ctx
+}
+
+func (c *TcpClient) DoSomething(fn func(args ...any) bool) bool {
+	return fn()
+}
+func (c *TcpClient) CheckSomething(fn func(args ...any) bool) bool {
+	return fn()
+}
+func (c *TcpClient) Heartbeat() {
+	c.heartbeatTime.Store(time.Now().UnixNano())
+}
+
+func (c *TcpClient) IsHeartbeatTimeout(now time.Time) bool {
+	return now.After(time.Unix(0, c.heartbeatTime.Load()).Add(c.options.heartbeatTimeout))
+}
+
+// SendMsg 消息先进入发送队列, 重连期间的消息会在连上后发送
+func (c *TcpClient) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	if c.ctx.Err() != nil {
+		return errcode.ERR_NET_SESSION_CLOSED
+	}
+	sendBytes, err := fn(args...)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.outChan <- sendBytes:
+		return nil
+	default:
+		return errcode.ERR_NET_SEND_TIMEOUT
+	}
+}
+
+func (c *TcpClient) run() {
+	c.connMu.RLock()
+	conn, cph := c.conn, c.cipher
+	c.connMu.RUnlock()
+	for {
+		c.serve(conn, cph)
+
+		if !c.options.reconnect {
+			break
+		}
+		if conn, cph = c.reconnect(); conn == nil {
+			break
+		}
+	}
+
+	c.Close()
+}
+
+// serve 处理一次连接, 连接断开后返回
+func (c *TcpClient) serve(conn net.Conn, cph *frame_cipher.Cipher) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	c.hooks.ExecuteStart(c)
+
+	done := make(chan struct{})
+	go tools.GoSafe("tcp_client write pump", func() {
+		defer close(done)
+		c.writePump(ctx, conn, cph)
+	})
+
+	c.readPump(conn, cph)
+
+	cancel()
+	<-done
+
+	c.hooks.ExecuteStop(c)
+}
+
+func (c *TcpClient) reconnect() (net.Conn, *frame_cipher.Cipher) {
+	backoff := c.options.reconnectMin
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-c.ctx.Done():
+			return nil, nil
+		}
+
+		conn, cph, err := c.dial()
+		if err == nil {
+			c.connMu.Lock()
+			c.conn, c.cipher = conn, cph
+			c.connMu.Unlock()
+			c.Heartbeat()
+
+			// Close 与重连同时发生
+			if c.ctx.Err() != nil {
+				conn.Close()
+				return nil, nil
+			}
+			return conn, cph
+		}
+		log.Warn("tcp_client reconnect err", zap.String("addr", c.options.addr),
+			zap.Duration("backoff", backoff), zap.Error(err))
+
+		backoff = min(backoff*2, c.options.reconnectMax)
+	}
+}
+
+func (c *TcpClient) readPump(conn net.Conn, cph *frame_cipher.Cipher) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, enums.READ_BUFF_SIZE_INIT), c.options.codec.MaxPacketSize())
+	scanner.Split(codec.SplitWith(c.options.codec))
+LOOP:
+	for scanner.Scan() {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		if cph != nil {
+			var err error
+			if data, err = cph.Open(data); err != nil {
+				log.Warn("tcp_client decrypt err", zap.String("addr", c.options.addr), zap.Error(err))
+				break LOOP
+			}
+		}
+		c.Heartbeat()
+
+		buf := buffer_pool.GetBuffer()
+		if buf == nil {
+			log.Error("buffer_pool get err")
+			break LOOP
+		}
+
+		buf.Data = ensureCapacity(buf.Data, len(data))
+		copy(buf.Data, data)
+
+		c.hooks.ExecuteRecv(c, buf.Data)
+		buffer_pool.Put(buf)
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Warn("tcp_client read err", zap.String("addr", c.options.addr), zap.Error(err))
+	}
+}
+
+func (c *TcpClient) writePump(ctx context.Context, conn net.Conn, cph *frame_cipher.Cipher) {
+	defer conn.Close()
+
+	var (
+		heartbeatC <-chan time.Time
+
+		batch = make(net.Buffers, 0, c.options.writeBatch)
+	)
+	if c.options.heartbeatInterval > 0 && c.options.heartbeatFn != nil {
+		ticker := time.NewTicker(c.options.heartbeatInterval)
+		defer ticker.Stop()
+		heartbeatC = ticker.C
+	}
+
+	for {
+		select {
+		case data := <-c.outChan:
+			batch = c.collect(batch[:0], data)
+			err := c.write(conn, cph, batch)
+			clear(batch)
+			if err != nil {
+				log.Warn("tcp_client write err", zap.String("addr", c.options.addr), zap.Error(err))
+				return
+			}
+		case now := <-heartbeatC:
+			if c.IsHeartbeatTimeout(now) {
+				log.Warn("tcp_client heartbeat timeout", zap.String("addr", c.options.addr))
+				return
+			}
+
+			data, err := c.options.heartbeatFn()
+			if err != nil {
+				log.Error("tcp_client heartbeat msg err", zap.Error(err))
+				continue
+			}
+			if err = c.write(conn, cph, net.Buffers{data}); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// collect 从发送队列中取出已有的帧, 最多 writeBatch 帧
+func (c *TcpClient) collect(batch net.Buffers, data []byte) net.Buffers {
+	batch = append(batch, data)
+	for len(batch) < c.options.writeBatch {
+		select {
+		case data := <-c.outChan:
+			batch = append(batch, data)
+		default:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+func (c *TcpClient) write(conn net.Conn, cph *frame_cipher.Cipher, bufs net.Buffers) (err error) {
+	if cph != nil {
+		for i := range bufs {
+			if bufs[i], err = cph.Seal(bufs[i]); err != nil {
+				return
+			}
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
+
+	_, err = bufs.WriteTo(conn)
+	return
+}
+
+func ensureCapacity(slice []byte, size int) []byte {
+	if cap(slice) >= size {
+		return slice[:size]
+	}
+	return make([]byte, size)
+}
@@ -0,0 +1,74 @@
+package tcp_client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTcpClientReconnect(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+	as.NoError(buffer_pool.Init(context.Background()))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	as.NoError(err)
+	defer ln.Close()
+
+	// 回显收到的第一帧后断开连接
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, codec.Get().HeaderSize()+1)
+			if _, err = io.ReadFull(conn, buf); err == nil {
+				conn.Write(buf)
+			}
+			conn.Close()
+		}
+	}()
+
+	var starts atomic.Int32
+	recv := make(chan []byte, 2)
+
+	c := NewTcpClient()
+	c.Hooks().OnStart(func(ss iface.ISession) {
+		starts.Add(1)
+	})
+	c.Hooks().OnRecv(func(ss iface.ISession, data any) {
+		recv <- append([]byte(nil), data.([]byte)...)
+	})
+	as.NoError(c.Init(context.Background(), WithAddr(ln.Addr().String()),
+		WithReconnect(10*time.Millisecond, 50*time.Millisecond)))
+	c.Start()
+	defer c.Stop()
+
+	for i := 0; i < 2; i++ {
+		as.NoError(c.SendMsg(func(args ...any) ([]byte, error) {
+			return codec.Pack(1, 0, 0, []byte{byte(i)}), nil
+		}))
+
+		select {
+		case data := <-recv:
+			frame, body, err := codec.Unpack(data)
+			as.NoError(err)
+			as.Equal(uint16(1), frame.MsgID)
+			as.Equal([]byte{byte(i)}, body)
+		case <-time.After(time.Second):
+			t.Fatal("recv timeout")
+		}
+	}
+	as.Equal(int32(2), starts.Load())
+}
@@ -27,3 +27,11 @@ func Start() {
 func Stop() {
 	defTcpSer.Stop()
 }
+
+func Shutdown(ctx context.Context) error {
+	return defTcpSer.Shutdown(ctx)
+}
+
+func ConnLen() int {
+	return defTcpSer.ConnLen()
+}
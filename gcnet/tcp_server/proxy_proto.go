@@ -0,0 +1,160 @@
+package tcp_server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	proxyV1Prefix = "PROXY "
+	proxyV1MaxLen = 107
+
+	proxyV2HeaderLen = 16
+	proxyV2CmdLocal  = 0x0
+	proxyV2CmdProxy  = 0x1
+	proxyV2FamTCP4   = 0x11
+	proxyV2FamTCP6   = 0x21
+)
+
+var proxyV2Sig = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+var (
+	ErrProxyHeaderMissing = errors.New("proxy protocol header missing")
+	ErrProxyHeaderInvalid = errors.New("proxy protocol header invalid")
+)
+
+// proxyConn 读取 PROXY 头后的连接, 已缓冲的数据继续从 reader 读出, RemoteAddr 返回真实客户端地址
+type proxyConn struct {
+	net.Conn
+
+	reader *bufio.Reader
+	remote net.Addr
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// newProxyConn 解析连接开头的 PROXY protocol v1/v2 头
+func newProxyConn(conn net.Conn) (net.Conn, error) {
+	r := bufio.NewReader(conn)
+	remote, err := readProxyHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		remote = conn.RemoteAddr()
+	}
+
+	return &proxyConn{Conn: conn, reader: r, remote: remote}, nil
+}
+
+// readProxyHeader 返回头中的源地址, LOCAL/UNKNOWN 时返回 nil
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(len(proxyV1Prefix))
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == proxyV1Prefix {
+		return readProxyV1(r)
+	}
+
+	b, err = r.Peek(len(proxyV2Sig))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(b, proxyV2Sig) {
+		return readProxyV2(r)
+	}
+
+	return nil, ErrProxyHeaderMissing
+}
+
+// readProxyV1 PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	line := make([]byte, 0, proxyV1MaxLen)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+		if len(line) >= proxyV1MaxLen {
+			return nil, ErrProxyHeaderInvalid
+		}
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, ErrProxyHeaderInvalid
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, ErrProxyHeaderInvalid
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, ErrProxyHeaderInvalid
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, ErrProxyHeaderInvalid
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyV2HeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[12]>>4 != 0x2 {
+		return nil, ErrProxyHeaderInvalid
+	}
+
+	body := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	switch header[12] & 0x0F {
+	case proxyV2CmdLocal:
+		return nil, nil
+	case proxyV2CmdProxy:
+	default:
+		return nil, ErrProxyHeaderInvalid
+	}
+
+	switch header[13] {
+	case proxyV2FamTCP4:
+		if len(body) < 12 {
+			return nil, ErrProxyHeaderInvalid
+		}
+		return &net.TCPAddr{IP: net.IP(body[:4]), Port: int(binary.BigEndian.Uint16(body[8:]))}, nil
+	case proxyV2FamTCP6:
+		if len(body) < 36 {
+			return nil, ErrProxyHeaderInvalid
+		}
+		return &net.TCPAddr{IP: net.IP(body[:16]), Port: int(binary.BigEndian.Uint16(body[32:]))}, nil
+	}
+
+	// UDP/UNIX 等地址族不关心, 保留原地址
+	return nil, nil
+}
@@ -0,0 +1,41 @@
+package tcp_server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestReadProxyHeader(t *testing.T) {
+	var as = assert.New(t)
+
+	r := bufio.NewReader(bytes.NewBufferString("PROXY TCP4 203.0.113.7 10.0.0.1 56324 9000\r\nhello"))
+	addr, err := readProxyHeader(r)
+	as.Nil(err)
+	as.Equal("203.0.113.7:56324", addr.String())
+	rest, _ := r.ReadString(0)
+	as.Equal("hello", rest)
+
+	addr, err = readProxyHeader(bufio.NewReader(bytes.NewBufferString("PROXY UNKNOWN\r\n")))
+	as.Nil(err)
+	as.Nil(addr)
+
+	v2 := append([]byte{}, proxyV2Sig...)
+	v2 = append(v2, 0x21, proxyV2FamTCP6, 0, 36)
+	v2 = append(v2, net.ParseIP("2001:db8::1")...)
+	v2 = append(v2, net.ParseIP("2001:db8::2")...)
+	v2 = binary.BigEndian.AppendUint16(v2, 443)
+	v2 = binary.BigEndian.AppendUint16(v2, 9000)
+	addr, err = readProxyHeader(bufio.NewReader(bytes.NewReader(v2)))
+	as.Nil(err)
+	as.Equal("[2001:db8::1]:443", addr.String())
+
+	_, err = readProxyHeader(bufio.NewReader(bytes.NewBufferString("GET / HTTP/1.1\r\n\r\n")))
+	as.Equal(ErrProxyHeaderMissing, err)
+
+	_, err = readProxyHeader(bufio.NewReader(bytes.NewBufferString("PROXY TCP4 ::1 10.0.0.1 1 2\r\n")))
+	as.Equal(ErrProxyHeaderInvalid, err)
+}
@@ -1,19 +1,65 @@
 package tcp_server
 
 import (
+	"context"
+	"crypto/tls"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/tcp_session"
 	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/middleware/conn_limiter"
+	"net"
+	"time"
 )
 
+// ListenFn 创建监听, 用于替换默认的 tcp 监听, 如 kcp_server.WithKcp
+type ListenFn func(ctx context.Context, addr string) (net.Listener, error)
+
 type TcpOption struct {
 	listenAddr string
+	listenFn   ListenFn
+
+	method iface.ISessionMethod
+	codec  iface.ICodec
+
+	shutdownMsg []byte
+
+	queueSize   int
+	sendPolicy  enums.SEND_POLICY
+	sendTimeout time.Duration
+	writeBatch  int
+
+	poolAssign tcp_session.PoolAssignFn
+
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+
+	tlsConfig *tls.Config
+	certFile  string
+	keyFile   string
+
+	keepAlive   time.Duration
+	noDelay     bool
+	readBuffer  int
+	writeBuffer int
+
+	maxConns int
+
+	proxyProtocol    bool
+	handshakeTimeout time.Duration
+
+	limiter *conn_limiter.ConnLimiter
 
-	method iface.ITcpSessionMethod
+	cipherSuite frame_cipher.SUITE
 }
 
 type Option func(opts *TcpOption)
 
 func NewTcpOption() *TcpOption {
-	o := &TcpOption{}
+	o := &TcpOption{
+		noDelay:          true,
+		handshakeTimeout: enums.CONN_HANDSHAKE_TIMEOUT,
+	}
 
 	return o
 }
@@ -24,8 +70,137 @@ func WithListenAddr(addr string) Option {
 	}
 }
 
-func WithMethod(m iface.ITcpSessionMethod) Option {
+func WithMethod(m iface.ISessionMethod) Option {
 	return func(opts *TcpOption) {
 		opts.method = m
 	}
 }
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *TcpOption) {
+		opts.codec = c
+	}
+}
+
+// WithShutdownMsg 关服时发送给所有会话的消息
+func WithShutdownMsg(msg []byte) Option {
+	return func(opts *TcpOption) {
+		opts.shutdownMsg = msg
+	}
+}
+
+// WithHeartbeat 每隔 interval 检查一次, 超过 timeout 未收到心跳的会话将被关闭
+func WithHeartbeat(interval, timeout time.Duration) Option {
+	return func(opts *TcpOption) {
+		opts.heartbeatInterval = interval
+		opts.heartbeatTimeout = timeout
+	}
+}
+
+// WithQueueSize 每个会话的发送队列长度
+func WithQueueSize(n int) Option {
+	return func(opts *TcpOption) {
+		opts.queueSize = n
+	}
+}
+
+// WithSendPolicy 会话发送队列满时的处理方式, timeout 仅用于 SEND_POLICY_BLOCK
+func WithSendPolicy(policy enums.SEND_POLICY, timeout time.Duration) Option {
+	return func(opts *TcpOption) {
+		opts.sendPolicy = policy
+		opts.sendTimeout = timeout
+	}
+}
+
+// WithWriteBatch 会话发送时最多合并 n 帧为一次写入, 1 表示不合并, 默认 enums.SESSION_WRITE_BATCH
+func WithWriteBatch(n int) Option {
+	return func(opts *TcpOption) {
+		opts.writeBatch = n
+	}
+}
+
+// WithPoolDispatch 会话收到的消息交给协程池处理, 如 worker_pool.AssignOrderedSessionTask
+func WithPoolDispatch(assign tcp_session.PoolAssignFn) Option {
+	return func(opts *TcpOption) {
+		opts.poolAssign = assign
+	}
+}
+
+// WithTLS 使用证书文件开启 TLS
+func WithTLS(certFile, keyFile string) Option {
+	return func(opts *TcpOption) {
+		opts.certFile = certFile
+		opts.keyFile = keyFile
+	}
+}
+
+// WithTLSConfig 使用自定义的 tls.Config 开启 TLS, 优先于 WithTLS
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(opts *TcpOption) {
+		opts.tlsConfig = cfg
+	}
+}
+
+// WithKeepAlive TCP keepalive 间隔, 0 使用系统默认, 负数关闭
+func WithKeepAlive(d time.Duration) Option {
+	return func(opts *TcpOption) {
+		opts.keepAlive = d
+	}
+}
+
+// WithNoDelay 是否关闭 Nagle 算法, 默认 true
+func WithNoDelay(noDelay bool) Option {
+	return func(opts *TcpOption) {
+		opts.noDelay = noDelay
+	}
+}
+
+// WithBufferSize socket 读写缓冲区大小, 0 使用系统默认
+func WithBufferSize(read, write int) Option {
+	return func(opts *TcpOption) {
+		opts.readBuffer = read
+		opts.writeBuffer = write
+	}
+}
+
+// WithMaxConns 最大连接数, 超过后新连接直接关闭, 0 不限制
+func WithMaxConns(n int) Option {
+	return func(opts *TcpOption) {
+		opts.maxConns = n
+	}
+}
+
+// WithProxyProtocol 开启后每个连接必须先发送 PROXY protocol v1/v2 头, 用于获取 L4 负载均衡后的真实 IP
+func WithProxyProtocol() Option {
+	return func(opts *TcpOption) {
+		opts.proxyProtocol = true
+	}
+}
+
+// WithHandshakeTimeout 读取 PROXY 头及 TLS 握手的超时
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(opts *TcpOption) {
+		opts.handshakeTimeout = d
+	}
+}
+
+// WithConnLimiter 按 IP 限制连接数、新建连接速率和消息速率
+func WithConnLimiter(l *conn_limiter.ConnLimiter) Option {
+	return func(opts *TcpOption) {
+		opts.limiter = l
+	}
+}
+
+// WithCipher 开启帧加密, 连接建立后客户端需先完成 frame_cipher 握手
+func WithCipher(suite frame_cipher.SUITE) Option {
+	return func(opts *TcpOption) {
+		opts.cipherSuite = suite
+	}
+}
+
+// WithListenFn 自定义监听, 此时 WithKeepAlive 不生效
+func WithListenFn(fn ListenFn) Option {
+	return func(opts *TcpOption) {
+		opts.listenFn = fn
+	}
+}
@@ -2,13 +2,20 @@ package tcp_server
 
 import (
 	"context"
+	"crypto/tls"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
 	"github.com/v587-zyf/gc/gcnet/tcp_session"
-	"github.com/v587-zyf/gc/gcnet/tcp_session_mgr"
+	"github.com/v587-zyf/gc/iface"
 	"github.com/v587-zyf/gc/log"
 	"go.uber.org/zap"
 	"kernel/tools"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type TcpServer struct {
@@ -17,7 +24,14 @@ type TcpServer struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	listener net.Listener
+	listener  net.Listener
+	tlsConfig *tls.Config
+
+	conns    atomic.Int64
+	sessions sync.Map // 本服务器创建的会话, 关服时只关闭这些
+
+	closing    atomic.Bool
+	handshakes sync.WaitGroup // 握手中的连接, 关服时等待其注册完成
 
 	wg sync.WaitGroup
 }
@@ -37,16 +51,34 @@ func (s *TcpServer) Init(ctx context.Context, option ...Option) (err error) {
 		opt(s.options)
 	}
 
-	s.listener, err = net.Listen("tcp", s.options.listenAddr)
+	if s.tlsConfig, err = s.loadTLSConfig(); err != nil {
+		log.Error("tcp_server load tls err", zap.Error(err))
+		return
+	}
+
+	if s.options.listenFn != nil {
+		s.listener, err = s.options.listenFn(s.ctx, s.options.listenAddr)
+	} else {
+		lc := net.ListenConfig{KeepAlive: s.options.keepAlive}
+		s.listener, err = lc.Listen(s.ctx, "tcp", s.options.listenAddr)
+	}
 	if err != nil {
 		log.Error("net listen err", zap.Error(err))
 		return
 	}
+	if s.options.heartbeatInterval > 0 {
+		// 心跳检查由所有服务器共同持有, 本服务器关闭后只释放自己的引用
+		context.AfterFunc(s.ctx, session_mgr.GetSessionMgr().Sweep(s.options.heartbeatInterval))
+	}
 
 	return nil
 }
 
 func (s *TcpServer) Start() {
+	go tools.GoSafe("tcp_server session mgr loop", func() {
+		session_mgr.GetSessionMgr().Start()
+	})
+
 	s.wg.Add(1)
 
 	go tools.GoSafe("tcp_server start listen", func() {
@@ -59,19 +91,211 @@ func (s *TcpServer) Start() {
 				//log.Error("tcp listen err", zap.Error(err))
 				break LOOP
 			}
-			ss := tcp_session.NewSession(context.Background(), c)
-			ss.Hooks().OnMethod(s.options.method)
-			ss.Start()
+			if n := s.conns.Add(1); s.options.maxConns > 0 && n > int64(s.options.maxConns) {
+				s.conns.Add(-1)
+				log.Warn("tcp_server max conns reached", zap.String("addr", c.RemoteAddr().String()))
+				c.Close()
+				continue
+			}
 
-			tcp_session_mgr.GetSessionMgr().AllAdd(ss)
+			s.handshakes.Add(1)
+			go tools.GoSafe("tcp_server handle conn", func() {
+				defer s.handshakes.Done()
+				s.handle(c)
+			})
 		}
 	})
 
 	s.Wait()
 }
 
+// handle 设置 socket 参数, 解析 PROXY 头并完成 TLS 握手后创建会话
+func (s *TcpServer) handle(c net.Conn) {
+	if tc, ok := c.(*net.TCPConn); ok {
+		tc.SetNoDelay(s.options.noDelay)
+		if s.options.readBuffer > 0 {
+			tc.SetReadBuffer(s.options.readBuffer)
+		}
+		if s.options.writeBuffer > 0 {
+			tc.SetWriteBuffer(s.options.writeBuffer)
+		}
+	}
+	secure := s.tlsConfig != nil || s.options.cipherSuite != frame_cipher.SUITE_NONE
+	if s.options.handshakeTimeout > 0 && (s.options.proxyProtocol || secure) {
+		c.SetDeadline(time.Now().Add(s.options.handshakeTimeout))
+	}
+
+	conn, err := s.handshake(c)
+	if err != nil {
+		log.Warn("tcp_server handshake err", zap.String("addr", c.RemoteAddr().String()), zap.Error(err))
+		c.Close()
+		s.conns.Add(-1)
+		return
+	}
+
+	ip, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	limiter := s.options.limiter
+	if limiter != nil && !limiter.Accept(ip) {
+		c.Close()
+		s.conns.Add(-1)
+		return
+	}
+
+	conn, cph, err := s.secure(conn)
+	if err != nil {
+		log.Warn("tcp_server secure handshake err", zap.String("addr", c.RemoteAddr().String()), zap.Error(err))
+		c.Close()
+		s.conns.Add(-1)
+		if limiter != nil {
+			limiter.Release(ip)
+		}
+		return
+	}
+	c.SetDeadline(time.Time{})
+	// 关服开始后完成握手的连接不再创建会话
+	if s.closing.Load() {
+		conn.Close()
+		s.conns.Add(-1)
+		if limiter != nil {
+			limiter.Release(ip)
+		}
+		return
+	}
+
+	opts := []tcp_session.Option{
+		tcp_session.WithCodec(s.options.codec),
+		tcp_session.WithHeartbeatTimeout(s.options.heartbeatTimeout),
+		tcp_session.WithQueueSize(s.options.queueSize),
+		tcp_session.WithSendPolicy(s.options.sendPolicy, s.options.sendTimeout),
+		tcp_session.WithWriteBatch(s.options.writeBatch),
+		tcp_session.WithPoolDispatch(s.options.poolAssign),
+	}
+	if cph != nil {
+		opts = append(opts, tcp_session.WithCipher(cph))
+	}
+	if limiter != nil {
+		opts = append(opts, tcp_session.WithRecvFilter(s.recvFilter))
+	}
+
+	ss := tcp_session.NewSession(context.Background(), conn, opts...)
+	ss.Set("ip", ip)
+	ss.Hooks().OnStop(func(iface.ISession) {
+		s.sessions.Delete(ss)
+		s.conns.Add(-1)
+		if limiter != nil {
+			limiter.Release(ip)
+		}
+	})
+	ss.Hooks().OnMethod(s.options.method)
+	s.sessions.Store(ss, struct{}{})
+	// 先加入管理器再启动, 保证 Start 钩子中的 Login 和立即断开的 Close 都能找到会话
+	session_mgr.GetSessionMgr().AllAdd(ss)
+	ss.Start()
+}
+
+// handshake 解析 PROXY 头, 未开启时直接返回原连接
+func (s *TcpServer) handshake(c net.Conn) (net.Conn, error) {
+	if !s.options.proxyProtocol {
+		return c, nil
+	}
+
+	return newProxyConn(c)
+}
+
+// secure 依次完成 TLS 握手和帧加密握手
+func (s *TcpServer) secure(conn net.Conn) (net.Conn, *frame_cipher.Cipher, error) {
+	if s.tlsConfig != nil {
+		tc := tls.Server(conn, s.tlsConfig)
+		if err := tc.HandshakeContext(s.ctx); err != nil {
+			return nil, nil, err
+		}
+		conn = tc
+	}
+	if s.options.cipherSuite == frame_cipher.SUITE_NONE {
+		return conn, nil, nil
+	}
+
+	cph, err := frame_cipher.ServerHandshake(frame_cipher.NewStreamConn(conn, s.codec()), s.codec(), s.options.cipherSuite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, cph, nil
+}
+
+// recvFilter 按 msgID 对会话限流
+func (s *TcpServer) recvFilter(ss iface.ISession, data []byte) bool {
+	var frame iface.MessageFrame
+	if err := s.codec().DecodeHeader(data, &frame); err != nil {
+		return true
+	}
+
+	return s.options.limiter.AllowMsg(ss, frame.MsgID)
+}
+
+func (s *TcpServer) codec() iface.ICodec {
+	if s.options.codec != nil {
+		return s.options.codec
+	}
+	return codec.Get()
+}
+
+func (s *TcpServer) loadTLSConfig() (*tls.Config, error) {
+	if s.options.tlsConfig != nil {
+		return s.options.tlsConfig, nil
+	}
+	if s.options.certFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.options.certFile, s.options.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// ConnLen 当前连接数, 包含握手中的连接
+func (s *TcpServer) ConnLen() int {
+	return int(s.conns.Load())
+}
+
 func (s *TcpServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), enums.SERVER_SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	s.Shutdown(ctx)
+}
+
+// Shutdown 停止接受新连接, 通知所有会话关服并在 ctx 结束前发送完队列中的消息
+func (s *TcpServer) Shutdown(ctx context.Context) (err error) {
+	s.closing.Store(true)
 	s.listener.Close()
+
+	// accept 协程退出后不会再有新的握手, 等待握手中的连接注册完成或被拒绝
+	s.Wait()
+	done := make(chan struct{})
+	go func() {
+		s.handshakes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	if err = session_mgr.GetSessionMgr().ShutdownFunc(ctx, s.options.shutdownMsg, s.owns); err != nil {
+		log.Warn("tcp_server shutdown err", zap.Error(err))
+	}
+	s.cancel()
+
+	return
+}
+
+func (s *TcpServer) owns(ss iface.ISession) bool {
+	_, ok := s.sessions.Load(ss)
+	return ok
 }
 
 func (s *TcpServer) Wait() {
@@ -0,0 +1,65 @@
+package tcp_server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"net"
+	"testing"
+	"time"
+)
+
+type nopMethod struct{}
+
+func (nopMethod) Name() string { return "nop" }
+
+func (nopMethod) Start(ss iface.ISession) {}
+
+func (nopMethod) Recv(ss iface.ISession, data any) {}
+
+func (nopMethod) Stop(ss iface.ISession) {}
+
+func TestShutdownHandshake(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+	as.NoError(buffer_pool.Init(context.Background()))
+
+	s := NewTcpServer()
+	as.NoError(s.Init(context.Background(), WithListenAddr("127.0.0.1:0"), WithMethod(nopMethod{}), WithProxyProtocol()))
+	go s.Start()
+
+	c, err := net.Dial("tcp", s.listener.Addr().String())
+	as.NoError(err)
+	defer c.Close()
+	as.Eventually(func() bool { return s.ConnLen() == 1 }, time.Second, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		s.Shutdown(ctx)
+		close(done)
+	}()
+	as.Eventually(s.closing.Load, time.Second, 10*time.Millisecond)
+
+	// 关服时仍在握手的连接完成握手后被拒绝, 不会在关服后留下会话
+	_, err = c.Write([]byte("PROXY TCP4 1.1.1.1 2.2.2.2 1000 2000\r\n"))
+	as.NoError(err)
+	<-done
+
+	n := 0
+	s.sessions.Range(func(key, value any) bool {
+		n++
+		return true
+	})
+	as.Zero(n)
+	as.Zero(s.ConnLen())
+
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = c.Read(make([]byte, 1))
+	as.Error(err)
+}
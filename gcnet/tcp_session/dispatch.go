@@ -0,0 +1,43 @@
+package tcp_session
+
+import (
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+)
+
+// RecvFilter 消息分发前调用, 返回 false 丢弃该消息, 如按 IP 限流
+type RecvFilter func(ss iface.ISession, data []byte) bool
+
+// PoolAssignFn 向协程池提交任务, 如 worker_pool.AssignOrderedSessionTask
+type PoolAssignFn func(fn Recv, ss iface.ISession, data any) error
+
+func (s *Session) dispatch(message []byte) bool {
+	if s.options.poolAssign == nil {
+		buf := buffer_pool.GetBuffer()
+		if buf == nil {
+			log.Error("buffer_pool get err")
+			return false
+		}
+
+		buf.Data = ensureCapacity(buf.Data, len(message))
+		copy(buf.Data, message)
+
+		s.hooks.ExecuteRecv(s, buf.Data)
+		buffer_pool.Put(buf)
+		return true
+	}
+
+	// 异步执行时回调可能晚于下一次读取, 不能复用缓冲区
+	data := make([]byte, len(message))
+	copy(data, message)
+
+	for _, fn := range s.hooks.recvFns() {
+		if err := s.options.poolAssign(fn, s, data); err != nil {
+			log.Warn("tcp_session dispatch err", zap.Uint64("userID", s.id), zap.Error(err))
+		}
+	}
+
+	return true
+}
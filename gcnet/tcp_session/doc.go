@@ -4,6 +4,6 @@ import (
 	"github.com/v587-zyf/gc/iface"
 )
 
-type Recv func(conn iface.ITcpSession, data any)
+type Recv = iface.SessionRecv
 
-type Call func(ss iface.ITcpSession)
+type Call func(ss iface.ISession)
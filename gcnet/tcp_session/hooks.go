@@ -42,7 +42,7 @@ func (h *Hooks) OnStop(fns ...Call) {
 	h.onStopFns = append(h.onStopFns, fns...)
 }
 
-func (h *Hooks) OnMethod(method iface.ITcpSessionMethod) {
+func (h *Hooks) OnMethod(method iface.ISessionMethod) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -60,20 +60,28 @@ func (h *Hooks) OnHooks(hooks *Hooks) {
 	h.onStopFns = append(h.onStopFns, hooks.onStopFns...)
 }
 
-func (h *Hooks) ExecuteStart(ss iface.ITcpSession) {
+func (h *Hooks) ExecuteStart(ss iface.ISession) {
 	for _, v := range h.onStartFns {
 		v(ss)
 	}
 }
 
-func (h *Hooks) ExecuteRecv(ss iface.ITcpSession, data []byte) {
+func (h *Hooks) ExecuteRecv(ss iface.ISession, data []byte) {
 	for _, v := range h.onRecvFns {
 		v(ss, data)
 	}
 }
 
-func (h *Hooks) ExecuteStop(ss iface.ITcpSession) {
+func (h *Hooks) ExecuteStop(ss iface.ISession) {
 	for _, v := range h.onStopFns {
 		v(ss)
 	}
 }
+
+// recvFns 返回当前的 Recv 回调, 交给协程池时使用
+func (h *Hooks) recvFns() []Recv {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]Recv(nil), h.onRecvFns...)
+}
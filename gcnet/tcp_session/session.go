@@ -1,24 +1,28 @@
 package tcp_session
 
 import (
+	"bufio"
 	"context"
-	"encoding/binary"
-	"github.com/v587-zyf/gc/buffer_pool"
+	"errors"
 	"github.com/v587-zyf/gc/enums"
 	"github.com/v587-zyf/gc/errcode"
-	"github.com/v587-zyf/gc/gcnet/tcp_session_mgr"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
+	"github.com/v587-zyf/gc/iface"
 	"github.com/v587-zyf/gc/log"
 	"go.uber.org/zap"
-	"io"
 	"kernel/tools"
 	"math"
 	"net"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Session struct {
+	options *SessionOption
+
 	id   uint64
 	conn net.Conn
 
@@ -29,25 +33,34 @@ type Session struct {
 	cache sync.Map
 
 	outChan chan []byte
-	isClose bool
+	isClose atomic.Bool
+	closeMu sync.Mutex
+	closeCh chan struct{}
+
+	dropped atomic.Uint64
 
-	heartbeatTime time.Time
+	heartbeatTime atomic.Int64
 }
 
-func NewSession(ctx context.Context, conn net.Conn) *Session {
+func NewSession(ctx context.Context, conn net.Conn, opts ...Option) *Session {
 	ctx, cancel := context.WithCancel(ctx)
 	s := &Session{
+		options: NewSessionOption(),
+
 		ctx:    ctx,
 		cancel: cancel,
 
-		outChan: make(chan []byte, 1024),
-
-		hooks: NewHooks(),
-
-		heartbeatTime: time.Now(),
+		hooks:   NewHooks(),
+		closeCh: make(chan struct{}),
 	}
 	s.conn = conn
 
+	for _, opt := range opts {
+		opt(s.options)
+	}
+	s.outChan = make(chan []byte, s.options.queueSize)
+	s.Heartbeat()
+
 	return s
 }
 
@@ -92,32 +105,52 @@ func (s *Session) SetID(id uint64) {
 }
 
 func (s *Session) Close() error {
-	if !s.isClose {
-		s.isClose = true
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
 
-		s.hooks.ExecuteStop(s)
+	if !s.isClose.Load() {
+		s.isClose.Store(true)
 
-		close(s.outChan)
+		s.hooks.ExecuteStop(s)
 
+		// 不关闭 outChan, 避免并发的 SendMsg 向已关闭的 channel 发送, IOPump 随 ctx 退出
 		s.cancel()
 		s.conn.Close()
+		close(s.closeCh)
 
-		tcp_session_mgr.GetSessionMgr().UnRegisterCh <- s
+		session_mgr.GetSessionMgr().UnRegisterCh <- s
 	}
 
 	return nil
 }
 
+// Shutdown 停止会话并在 ctx 结束前尽量发送完队列中的消息
+func (s *Session) Shutdown(ctx context.Context) error {
+	s.cancel()
+
+	select {
+	case <-s.closeCh:
+		return nil
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
 func (s *Session) GetConn() net.Conn {
 	return s.conn
 }
 
+func (s *Session) GetCodec() iface.ICodec {
+	return s.options.codec
+}
+
 func (s *Session) GetCtx() context.Context {
 	return s.ctx
 }
 
 func (s *Session) Login() {
-	tcp_session_mgr.GetSessionMgr().LoginCh <- s
+	session_mgr.GetSessionMgr().LoginCh <- s
 }
 
 func (s *Session) DoSomething(fn func(args ...any) bool) bool {
@@ -127,25 +160,71 @@ func (s *Session) CheckSomething(fn func(args ...any) bool) bool {
 	return fn()
 }
 func (s *Session) Heartbeat() {
-	s.heartbeatTime = time.Now()
+	s.heartbeatTime.Store(time.Now().UnixNano())
 }
 
 func (s *Session) IsHeartbeatTimeout(now time.Time) bool {
-	return now.After(s.heartbeatTime.Add(enums.HEARTBEAT_TIMEOUT))
+	return now.After(time.Unix(0, s.heartbeatTime.Load()).Add(s.options.heartbeatTimeout))
 }
 
 func (s *Session) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	if s.isClose.Load() {
+		return errcode.ERR_NET_SESSION_CLOSED
+	}
 	sendBytes, err := fn(args...)
 	if err != nil {
 		return err
 	}
 
+	return s.push(sendBytes)
+}
+
+// push 将消息放入发送队列, 队列满时按 sendPolicy 处理
+func (s *Session) push(data []byte) error {
 	select {
-	case s.outChan <- sendBytes:
+	case s.outChan <- data:
 		return nil
 	default:
-		return errcode.ERR_NET_SEND_TIMEOUT
 	}
+
+	switch s.options.sendPolicy {
+	case enums.SEND_POLICY_DROP_OLDEST:
+		for {
+			select {
+			case <-s.outChan:
+				s.dropped.Add(1)
+			default:
+			}
+			select {
+			case s.outChan <- data:
+				return nil
+			default:
+			}
+		}
+	case enums.SEND_POLICY_DISCONNECT:
+		s.dropped.Add(1)
+		log.Warn("tcp_session send queue full, disconnect", zap.Uint64("userID", s.id), zap.Int("queue", cap(s.outChan)))
+		go s.Close()
+		return errcode.ERR_NET_SESSION_CLOSED
+	case enums.SEND_POLICY_BLOCK:
+		timer := time.NewTimer(s.options.sendTimeout)
+		defer timer.Stop()
+
+		select {
+		case s.outChan <- data:
+			return nil
+		case <-timer.C:
+		case <-s.ctx.Done():
+		}
+	}
+
+	s.dropped.Add(1)
+	return errcode.ERR_NET_SEND_TIMEOUT
+}
+
+// Dropped 因发送队列满而丢弃的消息数
+func (s *Session) Dropped() uint64 {
+	return s.dropped.Load()
 }
 
 func (s *Session) readPump() {
@@ -168,58 +247,35 @@ func (s *Session) readPump() {
 
 	s.hooks.ExecuteStart(s)
 
-	//	scanner := bufio.NewScanner(s.conn)
-	//	scanner.Buffer(make([]byte, enums.READ_BUFF_SIZE_INIT), enums.READ_BUFF_SIZE_MAX)
-	//	scanner.Split(s.split)
-	//LOOP:
-	//	for {
-	//		ok := scanner.Scan()
-	//		if !ok {
-	//			if err := scanner.Err(); err != nil {
-	//				log.Error("server read err", zap.Error(err))
-	//			}
-	//			break LOOP
-	//		}
-	//
-	//		data := scanner.Bytes()
-	//		if len(data) > 0 {
-	//			dataCopy := make([]byte, len(data))
-	//			copy(dataCopy, data)
-	//			select {
-	//			case s.inChan <- dataCopy:
-	//			default:
-	//				log.Warn("inChan is full, dropping message", zap.Uint64("sessID", s.GetID()))
-	//			}
-	//		}
-	//	}
-
-	buffer := make([]byte, enums.READ_BUFF_SIZE_INIT)
+	scanner := bufio.NewScanner(s.conn)
+	scanner.Buffer(make([]byte, enums.READ_BUFF_SIZE_INIT), s.options.codec.MaxPacketSize())
+	scanner.Split(codec.SplitWith(s.options.codec))
 LOOP:
-	for {
-		n, err := s.conn.Read(buffer)
-		if err != nil {
-			if err == io.EOF {
-				break LOOP
-			}
-			//log.Error("server read err", zap.Error(err))
-			break LOOP
+	for scanner.Scan() {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
 		}
-
-		data := buffer[:n]
-		if len(data) > 0 {
-			buf := buffer_pool.GetBuffer()
-			if buf == nil {
-				log.Error("buffer_pool get err")
+		if s.options.cipher != nil {
+			var err error
+			if data, err = s.options.cipher.Open(data); err != nil {
+				log.Warn("tcp_session decrypt err", zap.Uint64("sessID", s.GetID()),
+					zap.String("addr", s.conn.RemoteAddr().String()), zap.Error(err))
 				break LOOP
 			}
+		}
+		if s.options.recvFilter != nil && !s.options.recvFilter(s, data) {
+			continue
+		}
 
-			buf.Data = ensureCapacity(buf.Data, len(data))
-			copy(buf.Data, data)
-
-			s.hooks.ExecuteRecv(s, buf.Data)
-			buffer_pool.Put(buf)
+		if !s.dispatch(data) {
+			break LOOP
 		}
 	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Warn("tcp_session read err", zap.Uint64("sessID", s.GetID()),
+			zap.String("addr", s.conn.RemoteAddr().String()), zap.Error(err))
+	}
 
 	s.cancel()
 }
@@ -259,11 +315,12 @@ LOOP:
 		case data := <-s.outChan:
 			s.conn.SetWriteDeadline(time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
 
-			_, err := s.conn.Write(data)
+			_, err := s.conn.Write(s.seal(data))
 			if err != nil {
-				msgID := binary.BigEndian.Uint16(data[0:2])
+				var frame iface.MessageFrame
+				s.options.codec.DecodeHeader(data, &frame)
 				log.Warn("conn write err", zap.Uint64("userID", s.id),
-					zap.Uint16("msgID", msgID), zap.Int("len", len(data)), zap.Error(err))
+					zap.Uint16("msgID", frame.MsgID), zap.Int("len", len(data)), zap.Error(err))
 				break LOOP
 			}
 		case <-s.ctx.Done():
@@ -298,54 +355,97 @@ func (s *Session) IOPump() {
 	var (
 		err     error
 		backoff time.Duration
+
+		batch = make(net.Buffers, 0, s.options.writeBatch)
 	)
 
 LOOP:
 	for {
 		select {
 		case data := <-s.outChan:
+			// 合并队列中已有的帧, TCPConn 使用 writev 一次写入
+			batch = s.collect(batch[:0], data)
+			bufs := batch
 			for i := 0; i < 3; i++ {
 				//s.conn.SetWriteDeadline(time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
-				if _, err = s.conn.Write(data); err == nil {
+				if _, err = bufs.WriteTo(s.conn); err == nil {
 					break
 				}
 				backoff = calculateBackoff(i)
 				time.Sleep(backoff)
 			}
+			clear(batch)
+			if err != nil {
+				var frame iface.MessageFrame
+				s.options.codec.DecodeHeader(data, &frame)
+				log.Warn("tcp_session write err", zap.Uint64("userID", s.id),
+					zap.Uint16("msgID", frame.MsgID), zap.Error(err))
+				break LOOP
+			}
 		case <-s.ctx.Done():
+			s.drain()
 			break LOOP
 		}
 	}
 
-	s.conn.Close()
-	s.cancel()
+	s.Close()
+}
 
-	s.hooks.ExecuteStop(s)
+// collect 从发送队列中取出已有的帧, 最多 writeBatch 帧
+func (s *Session) collect(batch net.Buffers, data []byte) net.Buffers {
+	batch = s.appendSealed(batch, data)
+	for len(batch) < s.options.writeBatch {
+		select {
+		case data := <-s.outChan:
+			batch = s.appendSealed(batch, data)
+		default:
+			return batch
+		}
+	}
+
+	return batch
 }
 
-func calculateBackoff(attempt int) time.Duration {
-	return time.Duration(100) * time.Millisecond * time.Duration(math.Min(math.Pow(2, float64(attempt)), float64(time.Second/time.Millisecond)))
+func (s *Session) drain() {
+	s.conn.SetWriteDeadline(time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
+
+	var bufs net.Buffers
+LOOP:
+	for {
+		select {
+		case data := <-s.outChan:
+			bufs = s.appendSealed(bufs, data)
+		default:
+			break LOOP
+		}
+	}
+
+	bufs.WriteTo(s.conn)
 }
 
-func (s *Session) split(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if atEOF && len(data) == 0 {
-		return 0, nil, nil
+// seal 开启加密时在写协程中加密, 保证序号与发送顺序一致
+func (s *Session) seal(data []byte) []byte {
+	if s.options.cipher == nil {
+		return data
 	}
-	dataLen := len(data)
-	if dataLen < enums.MSG_HEADER_SIZE {
-		return 0, nil, nil
+
+	sealed, err := s.options.cipher.Seal(data)
+	if err != nil {
+		log.Warn("tcp_session encrypt err", zap.Uint64("userID", s.id), zap.Error(err))
+		return nil
 	}
 
-	// body len
-	n := int(binary.LittleEndian.Uint32(data[0:4]))
-	//if n > enums.MSG_MAX_PACKET_SIZE-enums.MSG_HEADER_SIZE || n < 0 {
-	//	log.Error("body len invalid", zap.Uint64("sessID", s.id),
-	//		zap.Int("n", n), zap.String("addr", s.GetConn().RemoteAddr().String()))
-	//	return 0, nil, errors.New("body len invalid")
-	//}
-	if dataLen < n+enums.MSG_HEADER_SIZE {
-		return 0, nil, nil
+	return sealed
+}
+
+func (s *Session) appendSealed(bufs net.Buffers, data []byte) net.Buffers {
+	if data = s.seal(data); data == nil {
+		return bufs
 	}
 
-	return n + enums.MSG_HEADER_SIZE, data[:n+enums.MSG_HEADER_SIZE], nil
+	return append(bufs, data)
+}
+
+func calculateBackoff(attempt int) time.Duration {
+	return time.Duration(100) * time.Millisecond * time.Duration(math.Min(math.Pow(2, float64(attempt)), float64(time.Second/time.Millisecond)))
 }
@@ -0,0 +1,105 @@
+package tcp_session
+
+import (
+	"bufio"
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/log"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// go test -run ^$ -bench IOPump ./gcnet/tcp_session/
+// syscw/op 为每帧的 write 系统调用次数(仅 linux)
+func BenchmarkIOPump(b *testing.B) {
+	if err := log.Init(context.Background(), log.WithInfoPath(b.TempDir()), log.WithErrPath(b.TempDir())); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("batch=1", func(b *testing.B) {
+		benchmarkIOPump(b, 1)
+	})
+	b.Run("batch=64", func(b *testing.B) {
+		benchmarkIOPump(b, enums.SESSION_WRITE_BATCH)
+	})
+}
+
+func benchmarkIOPump(b *testing.B, batch int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer ln.Close()
+
+	frame := codec.Pack(1, 0, 0, make([]byte, 16))
+	total := int64(len(frame) * b.N)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		io.CopyN(io.Discard, conn, total)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	ss := NewSession(context.Background(), conn,
+		WithWriteBatch(batch),
+		WithSendPolicy(enums.SEND_POLICY_BLOCK, time.Second),
+	)
+	ss.Start()
+	defer ss.Close()
+
+	fn := func(args ...any) ([]byte, error) {
+		return frame, nil
+	}
+
+	b.SetBytes(int64(len(frame)))
+	b.ResetTimer()
+
+	start := syscw()
+	for i := 0; i < b.N; i++ {
+		if err = ss.SendMsg(fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+	<-done
+
+	b.StopTimer()
+	if start >= 0 {
+		b.ReportMetric(float64(syscw()-start)/float64(b.N), "syscw/op")
+	}
+}
+
+// syscw 读取进程的 write 系统调用次数
+func syscw() int64 {
+	f, err := os.Open("/proc/self/io")
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "syscw: "); ok {
+			n, _ := strconv.ParseInt(v, 10, 64)
+			return n
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,107 @@
+package tcp_session
+
+import (
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/iface"
+	"time"
+)
+
+type SessionOption struct {
+	codec iface.ICodec
+
+	queueSize   int
+	sendPolicy  enums.SEND_POLICY
+	sendTimeout time.Duration
+	writeBatch  int
+
+	recvFilter RecvFilter
+	cipher     *frame_cipher.Cipher
+	poolAssign PoolAssignFn
+
+	heartbeatTimeout time.Duration
+}
+
+type Option func(opts *SessionOption)
+
+func NewSessionOption() *SessionOption {
+	o := &SessionOption{
+		codec: codec.Get(),
+
+		queueSize:   enums.SESSION_QUEUE_SIZE,
+		sendPolicy:  enums.SEND_POLICY_DROP_NEWEST,
+		sendTimeout: enums.CONN_WRITE_WAIT_TIME,
+		writeBatch:  enums.SESSION_WRITE_BATCH,
+
+		heartbeatTimeout: enums.HEARTBEAT_TIMEOUT,
+	}
+
+	return o
+}
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *SessionOption) {
+		if c != nil {
+			opts.codec = c
+		}
+	}
+}
+
+// WithHeartbeatTimeout 超过 d 未收到心跳视为超时
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(opts *SessionOption) {
+		if d > 0 {
+			opts.heartbeatTimeout = d
+		}
+	}
+}
+
+// WithQueueSize 发送队列长度
+func WithQueueSize(n int) Option {
+	return func(opts *SessionOption) {
+		if n > 0 {
+			opts.queueSize = n
+		}
+	}
+}
+
+// WithSendPolicy 发送队列满时的处理方式, timeout 仅用于 SEND_POLICY_BLOCK
+func WithSendPolicy(policy enums.SEND_POLICY, timeout time.Duration) Option {
+	return func(opts *SessionOption) {
+		opts.sendPolicy = policy
+		if timeout > 0 {
+			opts.sendTimeout = timeout
+		}
+	}
+}
+
+// WithWriteBatch 发送时最多合并 n 帧为一次写入, 1 表示不合并
+func WithWriteBatch(n int) Option {
+	return func(opts *SessionOption) {
+		if n > 0 {
+			opts.writeBatch = n
+		}
+	}
+}
+
+// WithPoolDispatch 收到的消息交给协程池处理, 如 worker_pool.AssignOrderedSessionTask 保证同一会话按顺序处理
+func WithPoolDispatch(assign PoolAssignFn) Option {
+	return func(opts *SessionOption) {
+		opts.poolAssign = assign
+	}
+}
+
+// WithRecvFilter 消息分发前的过滤函数
+func WithRecvFilter(fn RecvFilter) Option {
+	return func(opts *SessionOption) {
+		opts.recvFilter = fn
+	}
+}
+
+// WithCipher 使用握手得到的 Cipher 加解密收发的帧
+func WithCipher(c *frame_cipher.Cipher) Option {
+	return func(opts *SessionOption) {
+		opts.cipher = c
+	}
+}
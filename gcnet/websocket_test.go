@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"github.com/gorilla/websocket"
 	"github.com/v587-zyf/gc/buffer_pool"
-	"github.com/v587-zyf/gc/gcnet/ws_handler"
+	"github.com/v587-zyf/gc/gcnet/handler"
 	"github.com/v587-zyf/gc/gcnet/ws_server"
 	"github.com/v587-zyf/gc/iface"
 	"github.com/v587-zyf/gc/log"
@@ -18,7 +18,7 @@ type MsgHandler struct{}
 
 var _ MsgHandler
 
-func Recv(s iface.IWsSession, data any) {
+func Recv(s iface.ISession, data any) {
 	//fmt.Println("recv---", string(data.([]byte)))
 	s.SendMsg(func(args ...any) ([]byte, error) {
 		return data.([]byte), nil
@@ -43,8 +43,8 @@ func TestConcurrentWebSocketRequests(t *testing.T) {
 	if err = log.Init(ctx, log.WithSerName("Test"), log.WithSkipCaller(2)); err != nil {
 		panic("Log Init err" + err.Error())
 	}
-	if err = ws_handler.Init(ctx, ws_handler.WithName("Test"), ws_handler.WithRecvFn(Recv)); err != nil {
-		t.Errorf("Failed to initialize ws_handler: %v", err)
+	if err = handler.Init(ctx, handler.WithName("Test"), handler.WithRecvFn(Recv)); err != nil {
+		t.Errorf("Failed to initialize handler: %v", err)
 	}
 
 	serverAddr := ":8080"
@@ -54,7 +54,7 @@ func TestConcurrentWebSocketRequests(t *testing.T) {
 	messagesPerUser := 3 // 每人发送3条消息
 
 	ws := ws_server.NewWsServer()
-	if err = ws.Init(ctx, ws_server.WithAddr(serverAddr), ws_server.WithMethod(ws_handler.Get()), ws_server.WithHttps(false)); err != nil {
+	if err = ws.Init(ctx, ws_server.WithAddr(serverAddr), ws_server.WithMethod(handler.Get()), ws_server.WithHttps(false)); err != nil {
 		t.Errorf("ws init err:%v", err)
 		return
 	}
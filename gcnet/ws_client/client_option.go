@@ -0,0 +1,117 @@
+package ws_client
+
+import (
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"net/http"
+	"time"
+)
+
+// HeartbeatFn 构造定时发送给服务器的心跳消息
+type HeartbeatFn func() ([]byte, error)
+
+type ClientOption struct {
+	url         string
+	header      http.Header
+	dialTimeout time.Duration
+
+	method iface.ISessionMethod
+	codec  iface.ICodec
+	cipher bool
+
+	reconnect    bool
+	reconnectMin time.Duration
+	reconnectMax time.Duration
+
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	heartbeatFn       HeartbeatFn
+}
+
+type Option func(opts *ClientOption)
+
+func NewClientOption() *ClientOption {
+	o := &ClientOption{
+		dialTimeout: enums.CONN_DIAL_TIMEOUT,
+		codec:       codec.Get(),
+
+		reconnectMin: enums.RECONNECT_MIN_INTERVAL,
+		reconnectMax: enums.RECONNECT_MAX_INTERVAL,
+
+		heartbeatTimeout: enums.HEARTBEAT_TIMEOUT,
+	}
+
+	return o
+}
+
+// WithURL 服务器地址, 如 ws://127.0.0.1:8080/ws
+func WithURL(url string) Option {
+	return func(opts *ClientOption) {
+		opts.url = url
+	}
+}
+
+// WithHeader 握手时附带的请求头
+func WithHeader(header http.Header) Option {
+	return func(opts *ClientOption) {
+		opts.header = header
+	}
+}
+
+func WithDialTimeout(d time.Duration) Option {
+	return func(opts *ClientOption) {
+		opts.dialTimeout = d
+	}
+}
+
+func WithMethod(m iface.ISessionMethod) Option {
+	return func(opts *ClientOption) {
+		opts.method = m
+	}
+}
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *ClientOption) {
+		if c != nil {
+			opts.codec = c
+		}
+	}
+}
+
+// WithReconnect 断线后自动重连, 重连间隔从 minInterval 开始翻倍, 最大为 maxInterval
+func WithReconnect(minInterval, maxInterval time.Duration) Option {
+	return func(opts *ClientOption) {
+		opts.reconnect = true
+		if minInterval > 0 {
+			opts.reconnectMin = minInterval
+		}
+		if maxInterval >= opts.reconnectMin {
+			opts.reconnectMax = maxInterval
+		}
+	}
+}
+
+// WithHeartbeat 每隔 interval 发送一次 fn 构造的心跳消息
+func WithHeartbeat(interval time.Duration, fn HeartbeatFn) Option {
+	return func(opts *ClientOption) {
+		opts.heartbeatInterval = interval
+		opts.heartbeatFn = fn
+	}
+}
+
+// WithHeartbeatTimeout 超过 d 未收到服务器消息视为断线
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(opts *ClientOption) {
+		if d > 0 {
+			opts.heartbeatTimeout = d
+		}
+	}
+}
+
+// WithCipher 连接后先完成 frame_cipher 握手, 用于开启了加密的服务器
+func WithCipher() Option {
+	return func(opts *ClientOption) {
+		opts.cipher = true
+	}
+}
@@ -0,0 +1,370 @@
+package ws_client
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/ws_session"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"kernel/tools"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WsClient 连接 ws_server 的客户端, 实现 iface.IWsSession, 可直接复用服务器的 Hooks 和 handler
+type WsClient struct {
+	options *ClientOption
+
+	id uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	hooks *ws_session.Hooks
+	cache sync.Map
+
+	connMu sync.RWMutex
+	conn   *websocket.Conn
+	cipher *frame_cipher.Cipher
+
+	outChan chan []byte
+	isClose bool
+	closeMu sync.Mutex
+	closeCh chan struct{}
+
+	heartbeatTime atomic.Int64
+}
+
+func NewWsClient() *WsClient {
+	c := &WsClient{
+		options: NewClientOption(),
+
+		hooks: ws_session.NewHooks(),
+
+		outChan: make(chan []byte, 1024),
+		closeCh: make(chan struct{}),
+	}
+
+	return c
+}
+
+// Init 连接服务器, 首次连接失败直接返回错误
+func (c *WsClient) Init(ctx context.Context, opts ...Option) (err error) {
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	for _, opt := range opts {
+		opt(c.options)
+	}
+	if c.options.method != nil {
+		c.hooks.OnMethod(c.options.method)
+	}
+
+	if c.conn, c.cipher, err = c.dial(); err != nil {
+		log.Error("ws_client dial err", zap.String("url", c.options.url), zap.Error(err))
+		return
+	}
+	c.Heartbeat()
+
+	return nil
+}
+
+func (c *WsClient) dial() (*websocket.Conn, *frame_cipher.Cipher, error) {
+	d := websocket.Dialer{HandshakeTimeout: c.options.dialTimeout}
+
+	conn, _, err := d.DialContext(c.ctx, c.options.url, c.options.header)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 服务器 ping 时回复 pong 并刷新心跳
+	conn.SetPingHandler(func(appData string) error {
+		c.Heartbeat()
+		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
+	})
+	if !c.options.cipher {
+		return conn, nil, nil
+	}
+
+	conn.SetReadDeadline(time.Now().Add(c.options.dialTimeout))
+	cph, err := frame_cipher.ClientHandshake(frame_cipher.NewWsConn(conn, websocket.BinaryMessage), c.options.codec)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	return conn, cph, nil
+}
+
+func (c *WsClient) Start() {
+	go tools.GoSafe("ws_client run", func() {
+		c.run()
+	})
+}
+
+func (c *WsClient) Stop() {
+	c.Close()
+}
+
+// Done 客户端关闭后返回的 chan 会被关闭
+func (c *WsClient) Done() <-chan struct{} {
+	return c.closeCh
+}
+
+func (c *WsClient) Hooks() *ws_session.Hooks {
+	return c.hooks
+}
+
+func (c *WsClient) Set(key string, value any) {
+	c.cache.Store(key, value)
+}
+func (c *WsClient) Get(key string) (any, bool) {
+	return c.cache.Load(key)
+}
+func (c *WsClient) Remove(key string) {
+	c.cache.Delete(key)
+}
+
+func (c *WsClient) GetID() uint64 {
+	return c.id
+}
+func (c *WsClient) SetID(id uint64) {
+	c.id = id
+}
+
+func (c *WsClient) Close() error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+
+	if !c.isClose {
+		c.isClose = true
+
+		c.cancel()
+		if conn := c.getConn(); conn != nil {
+			conn.Close()
+		}
+		close(c.closeCh)
+	}
+
+	return nil
+}
+
+func (c *WsClient) getConn() *websocket.Conn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+
+	return c.conn
+}
+
+func (c *WsClient) GetConn() iface.IConn {
+	return c.getConn()
+}
+
+func (c *WsClient) GetCodec() iface.ICodec {
+	return c.options.codec
+}
+
+func (c *WsClient) GetCtx() context.Context {
+	return c.ctx
+}
+
+func (c *WsClient) DoSomething(fn func(args ...any) bool) bool {
+	return fn()
+}
+func (c *WsClient) CheckSomething(fn func(args ...any) bool) bool {
+	return fn()
+}
+func (c *WsClient) Heartbeat() {
+	c.heartbeatTime.Store(time.Now().UnixNano())
+}
+
+func (c *WsClient) IsHeartbeatTimeout(now time.Time) bool {
+	return now.After(time.Unix(0, c.heartbeatTime.Load()).Add(c.options.heartbeatTimeout))
+}
+
+// SendMsg 消息先进入发送队列, 重连期间的消息会在连上后发送
+func (c *WsClient) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
+	if c.ctx.Err() != nil {
+		return errcode.ERR_NET_SESSION_CLOSED
+	}
+	sendBytes, err := fn(args...)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.outChan <- sendBytes:
+		return nil
+	default:
+		return errcode.ERR_NET_SEND_TIMEOUT
+	}
+}
+
+func (c *WsClient) run() {
+	c.connMu.RLock()
+	conn, cph := c.conn, c.cipher
+	c.connMu.RUnlock()
+	for {
+		c.serve(conn, cph)
+
+		if !c.options.reconnect {
+			break
+		}
+		if conn, cph = c.reconnect(); conn == nil {
+			break
+		}
+	}
+
+	c.Close()
+}
+
+// serve 处理一次连接, 连接断开后返回
+func (c *WsClient) serve(conn *websocket.Conn, cph *frame_cipher.Cipher) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	c.hooks.ExecuteStart(c)
+
+	done := make(chan struct{})
+	go tools.GoSafe("ws_client write pump", func() {
+		defer close(done)
+		c.writePump(ctx, conn, cph)
+	})
+
+	c.readPump(conn, cph)
+
+	cancel()
+	<-done
+
+	c.hooks.ExecuteStop(c)
+}
+
+func (c *WsClient) reconnect() (*websocket.Conn, *frame_cipher.Cipher) {
+	backoff := c.options.reconnectMin
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-c.ctx.Done():
+			return nil, nil
+		}
+
+		conn, cph, err := c.dial()
+		if err == nil {
+			c.connMu.Lock()
+			c.conn, c.cipher = conn, cph
+			c.connMu.Unlock()
+			c.Heartbeat()
+
+			// Close 与重连同时发生
+			if c.ctx.Err() != nil {
+				conn.Close()
+				return nil, nil
+			}
+			return conn, cph
+		}
+		log.Warn("ws_client reconnect err", zap.String("url", c.options.url),
+			zap.Duration("backoff", backoff), zap.Error(err))
+
+		backoff = min(backoff*2, c.options.reconnectMax)
+	}
+}
+
+func (c *WsClient) readPump(conn *websocket.Conn, cph *frame_cipher.Cipher) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err,
+				websocket.CloseNormalClosure,
+				websocket.CloseGoingAway,
+				websocket.CloseNoStatusReceived,
+				websocket.CloseAbnormalClosure) {
+				log.Warn("ws_client read err", zap.String("url", c.options.url), zap.Error(err))
+			}
+			return
+		}
+		if len(message) == 0 {
+			continue
+		}
+		if cph != nil {
+			if message, err = cph.Open(message); err != nil {
+				log.Warn("ws_client decrypt err", zap.String("url", c.options.url), zap.Error(err))
+				return
+			}
+		}
+		c.Heartbeat()
+
+		buf := buffer_pool.GetBuffer()
+		if buf == nil {
+			log.Error("buffer_pool get err")
+			return
+		}
+
+		buf.Data = ensureCapacity(buf.Data, len(message))
+		copy(buf.Data, message)
+
+		c.hooks.ExecuteRecv(c, buf.Data)
+		buffer_pool.Put(buf)
+	}
+}
+
+func (c *WsClient) writePump(ctx context.Context, conn *websocket.Conn, cph *frame_cipher.Cipher) {
+	defer conn.Close()
+
+	var heartbeatC <-chan time.Time
+	if c.options.heartbeatInterval > 0 && c.options.heartbeatFn != nil {
+		ticker := time.NewTicker(c.options.heartbeatInterval)
+		defer ticker.Stop()
+		heartbeatC = ticker.C
+	}
+
+	for {
+		select {
+		case data := <-c.outChan:
+			if err := c.write(conn, cph, data); err != nil {
+				log.Warn("ws_client write err", zap.String("url", c.options.url), zap.Error(err))
+				return
+			}
+		case now := <-heartbeatC:
+			if c.IsHeartbeatTimeout(now) {
+				log.Warn("ws_client heartbeat timeout", zap.String("url", c.options.url))
+				return
+			}
+
+			data, err := c.options.heartbeatFn()
+			if err != nil {
+				log.Error("ws_client heartbeat msg err", zap.Error(err))
+				continue
+			}
+			if err = c.write(conn, cph, data); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(time.Second))
+			return
+		}
+	}
+}
+
+func (c *WsClient) write(conn *websocket.Conn, cph *frame_cipher.Cipher, data []byte) (err error) {
+	if cph != nil {
+		if data, err = cph.Seal(data); err != nil {
+			return
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
+
+	return conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func ensureCapacity(slice []byte, size int) []byte {
+	if cap(slice) >= size {
+		return slice[:size]
+	}
+	return make([]byte, size)
+}
@@ -0,0 +1,136 @@
+package ws_client
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newServer 每个连接交给 fn 处理, fn 返回后断开
+func newServer(fn func(conn *websocket.Conn)) (*httptest.Server, string) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fn(conn)
+	}))
+
+	return srv, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func initTest(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+	assert.NoError(t, buffer_pool.Init(context.Background()))
+}
+
+func TestWsClientReconnect(t *testing.T) {
+	var as = assert.New(t)
+	initTest(t)
+
+	// 回显收到的第一帧后断开连接
+	srv, url := newServer(func(conn *websocket.Conn) {
+		if mt, data, err := conn.ReadMessage(); err == nil {
+			conn.WriteMessage(mt, data)
+		}
+	})
+	defer srv.Close()
+
+	var starts atomic.Int32
+	stopped := make(chan struct{}, 2)
+	recv := make(chan []byte, 2)
+
+	c := NewWsClient()
+	c.Hooks().OnStart("test", func(ss iface.ISession) {
+		starts.Add(1)
+	})
+	c.Hooks().OnStop("test", func(ss iface.ISession) {
+		stopped <- struct{}{}
+	})
+	c.Hooks().OnRecv("test", func(ss iface.ISession, data any) {
+		recv <- append([]byte(nil), data.([]byte)...)
+	})
+	as.NoError(c.Init(context.Background(), WithURL(url), WithReconnect(50*time.Millisecond, 100*time.Millisecond)))
+	c.Start()
+	defer c.Stop()
+
+	send := func(b byte) {
+		as.NoError(c.SendMsg(func(args ...any) ([]byte, error) {
+			return codec.Pack(1, 0, 0, []byte{b}), nil
+		}))
+	}
+	expect := func(b byte) {
+		select {
+		case data := <-recv:
+			frame, body, err := codec.Unpack(data)
+			as.NoError(err)
+			as.Equal(uint16(1), frame.MsgID)
+			as.Equal([]byte{b}, body)
+		case <-time.After(time.Second):
+			t.Fatal("recv timeout")
+		}
+	}
+
+	send(0)
+	expect(0)
+
+	// 断线后重连期间发送的消息进入队列, 连上后发出
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("disconnect timeout")
+	}
+	send(1)
+	expect(1)
+	as.Equal(int32(2), starts.Load())
+}
+
+func TestWsClientHeartbeatTimeout(t *testing.T) {
+	var as = assert.New(t)
+	initTest(t)
+
+	// 只读不回复, 客户端收不到任何消息
+	var beats atomic.Int32
+	srv, url := newServer(func(conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			beats.Add(1)
+		}
+	})
+	defer srv.Close()
+
+	c := NewWsClient()
+	as.NoError(c.Init(context.Background(), WithURL(url),
+		WithHeartbeat(10*time.Millisecond, func() ([]byte, error) {
+			return codec.Pack(0, 0, 0, nil), nil
+		}),
+		WithHeartbeatTimeout(50*time.Millisecond)))
+	c.Start()
+	defer c.Stop()
+
+	// 未开启重连, 心跳超时后客户端关闭
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat timeout not detected")
+	}
+	as.Greater(beats.Load(), int32(0))
+	as.ErrorIs(c.SendMsg(func(args ...any) ([]byte, error) {
+		return codec.Pack(1, 0, 0, nil), nil
+	}), errcode.ERR_NET_SESSION_CLOSED)
+}
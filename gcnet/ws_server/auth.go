@@ -0,0 +1,104 @@
+package ws_server
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Authenticator 在升级为 websocket 前校验握手请求, 返回的 userID 非 0 时会话自动登录
+type Authenticator interface {
+	Authenticate(r *http.Request) (userID uint64, err error)
+}
+
+type AuthenticatorFunc func(r *http.Request) (uint64, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (uint64, error) {
+	return f(r)
+}
+
+// AuthError 拒绝握手时返回的 HTTP 状态码, 其他错误按 401 处理
+type AuthError struct {
+	Status int
+	Err    error
+}
+
+func NewAuthError(status int, err error) *AuthError {
+	return &AuthError{Status: status, Err: err}
+}
+
+func (e *AuthError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+func authStatus(err error) int {
+	var ae *AuthError
+	if errors.As(err, &ae) && ae.Status != 0 {
+		return ae.Status
+	}
+	return http.StatusUnauthorized
+}
+
+// RequestToken 按 query 参数 key、请求头 key、Authorization: Bearer、子协议 [key, token] 的顺序取 token
+func RequestToken(r *http.Request, key string) string {
+	if v := r.URL.Query().Get(key); v != "" {
+		return v
+	}
+	if v := r.Header.Get(key); v != "" {
+		return v
+	}
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return v
+	}
+
+	protocols := websocket.Subprotocols(r)
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == key {
+			return protocols[i+1]
+		}
+	}
+
+	return ""
+}
+
+// checkOrigin 未配置白名单时允许所有来源, 支持完整 Origin, 主机名, * 和 *.example.com
+func (s *WsServer) checkOrigin(r *http.Request) bool {
+	if len(s.options.allowOrigins) == 0 {
+		return true
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	// 按不含端口的主机名匹配, 非默认端口的来源也能命中
+	host := u.Hostname()
+	for _, allow := range s.options.allowOrigins {
+		switch {
+		case allow == "*":
+			return true
+		case strings.EqualFold(allow, origin), strings.EqualFold(allow, host):
+			return true
+		case strings.HasPrefix(allow, "*."):
+			if strings.HasSuffix(strings.ToLower(host), strings.ToLower(allow[1:])) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package ws_server
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/log"
+	"github.com/v587-zyf/gc/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func tgInitData(botToken string, authDate time.Time) string {
+	values := url.Values{}
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	values.Set("user", `{"id":10001,"first_name":"test"}`)
+
+	check, _ := utils.UrlParamSort(values, nil, false)
+	secret := utils.TgGetHmacSha256([]byte("WebAppData"), []byte(botToken))
+	values.Set("hash", hex.EncodeToString(utils.TgGetHmacSha256(secret, []byte(check))))
+
+	return values.Encode()
+}
+
+func TestTgAuthenticator(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	a := NewTgAuthenticator("bot-token", time.Hour)
+
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Set("Authorization", "tma "+tgInitData("bot-token", time.Now()))
+	userID, err := a.Authenticate(r)
+	as.NoError(err)
+	as.Equal(uint64(10001), userID)
+
+	r = httptest.NewRequest(http.MethodGet, "/ws?"+TG_INIT_DATA_KEY+"="+url.QueryEscape(tgInitData("other", time.Now())), nil)
+	_, err = a.Authenticate(r)
+	as.Equal(http.StatusForbidden, authStatus(err))
+
+	r = httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Set("Authorization", "tma "+tgInitData("bot-token", time.Now().Add(-2*time.Hour)))
+	_, err = a.Authenticate(r)
+	as.Equal(http.StatusForbidden, authStatus(err))
+
+	_, err = a.Authenticate(httptest.NewRequest(http.MethodGet, "/ws", nil))
+	as.Equal(http.StatusUnauthorized, authStatus(err))
+}
+
+func TestCheckOrigin(t *testing.T) {
+	var as = assert.New(t)
+
+	s := NewWsServer()
+	as.NoError(s.Init(context.Background(), WithAllowOrigins("https://a.com", "*.b.com", "d.com")))
+
+	origin := func(o string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set("Origin", o)
+		return r
+	}
+	as.True(s.checkOrigin(origin("https://a.com")))
+	as.True(s.checkOrigin(origin("https://game.b.com")))
+	as.True(s.checkOrigin(origin("https://d.com:8443")))
+	as.False(s.checkOrigin(origin("https://a.com:8443")))
+	as.True(s.checkOrigin(origin("https://game.b.com:8443")))
+	as.False(s.checkOrigin(origin("https://c.com")))
+	as.False(s.checkOrigin(origin("https://evilb.com")))
+}
+
+func TestClientIP(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	s := NewWsServer()
+	as.NoError(s.Init(context.Background(), WithTrustedProxies("10.0.0.1", "192.168.0.0/16")))
+
+	req := func(remote, real, forwarded string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.RemoteAddr = remote
+		if real != "" {
+			r.Header.Set("X-Real-IP", real)
+		}
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-For", forwarded)
+		}
+		return r
+	}
+	// 不可信对端伪造的转发头被忽略
+	as.Equal("1.1.1.1", s.clientIP(req("1.1.1.1:1234", "2.2.2.2", "3.3.3.3")))
+	as.Equal("2.2.2.2", s.clientIP(req("10.0.0.1:1234", "2.2.2.2", "")))
+	// 跳过链路上的可信代理, 客户端伪造的最左侧地址不生效
+	as.Equal("3.3.3.3", s.clientIP(req("10.0.0.1:1234", "", "9.9.9.9, 3.3.3.3, 192.168.1.1")))
+	as.Equal("10.0.0.1", s.clientIP(req("10.0.0.1:1234", "", "")))
+
+	as.Error(NewWsServer().Init(context.Background(), WithTrustedProxies("bad")))
+}
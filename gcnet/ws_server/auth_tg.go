@@ -0,0 +1,55 @@
+package ws_server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/v587-zyf/gc/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TG_INIT_DATA_KEY Telegram Mini App 通过 ?initData=、请求头或 Authorization: tma <initData> 传递 initData
+const TG_INIT_DATA_KEY = "initData"
+
+type tgAuthenticator struct {
+	botToken string
+	maxAge   time.Duration
+}
+
+// NewTgAuthenticator 使用 utils.TgCheck 校验 Telegram initData, 返回 Telegram 用户 ID, maxAge 为 0 时不检查 auth_date
+func NewTgAuthenticator(botToken string, maxAge time.Duration) Authenticator {
+	return &tgAuthenticator{botToken: botToken, maxAge: maxAge}
+}
+
+func (a *tgAuthenticator) Authenticate(r *http.Request) (uint64, error) {
+	initData, ok := strings.CutPrefix(r.Header.Get("Authorization"), "tma ")
+	if !ok {
+		initData = RequestToken(r, TG_INIT_DATA_KEY)
+	}
+	if initData == "" {
+		return 0, NewAuthError(http.StatusUnauthorized, errors.New("initData required"))
+	}
+
+	values, ok := utils.TgCheck(initData, a.botToken)
+	if !ok {
+		return 0, NewAuthError(http.StatusForbidden, errors.New("initData invalid"))
+	}
+
+	if a.maxAge > 0 {
+		authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+		if err != nil || time.Since(time.Unix(authDate, 0)) > a.maxAge {
+			return 0, NewAuthError(http.StatusForbidden, errors.New("initData expired"))
+		}
+	}
+
+	var user struct {
+		ID uint64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return 0, NewAuthError(http.StatusForbidden, errors.New("initData user invalid"))
+	}
+
+	return user.ID, nil
+}
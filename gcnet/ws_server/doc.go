@@ -30,3 +30,7 @@ func Start() {
 func Stop() {
 	defWsSer.Stop()
 }
+
+func Shutdown(ctx context.Context) error {
+	return defWsSer.Shutdown(ctx)
+}
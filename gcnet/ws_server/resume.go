@@ -0,0 +1,65 @@
+package ws_server
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
+	"github.com/v587-zyf/gc/gcnet/ws_session"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 断线重连参数, 如 /ws?resume_token=xxx&resume_seq=10
+// resume_seq 为客户端在 Login 之后收到的消息条数
+const (
+	RESUME_TOKEN_PARAM = "resume_token"
+	RESUME_SEQ_PARAM   = "resume_seq"
+)
+
+func (s *WsServer) resumeHandle(w http.ResponseWriter, r *http.Request, token string) {
+	lastSeq, err := strconv.ParseUint(r.URL.Query().Get(RESUME_SEQ_PARAM), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid "+RESUME_SEQ_PARAM, http.StatusBadRequest)
+		return
+	}
+
+	v, ok := session_mgr.GetSessionMgr().Resume(token)
+	if !ok {
+		http.Error(w, errcode.ERR_NET_RESUME_FAILED.Error(), http.StatusGone)
+		return
+	}
+	ss, ok := v.(*ws_session.Session)
+	if !ok {
+		http.Error(w, errcode.ERR_NET_RESUME_FAILED.Error(), http.StatusGone)
+		return
+	}
+
+	wsConn, err := s.upGrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error("webSocket upgrade err:", zap.Error(err))
+		return
+	}
+	if s.options.readLimit > 0 {
+		wsConn.SetReadLimit(s.options.readLimit)
+	}
+
+	cph, err := s.handshake(wsConn)
+	if err != nil {
+		log.Warn("ws_server handshake err", zap.String("addr", r.RemoteAddr), zap.Error(err))
+		wsConn.Close()
+		return
+	}
+
+	if err = ss.Resume(wsConn, lastSeq, cph); err != nil {
+		// 会话已过期或缓存不足, 客户端需重新登录
+		msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error())
+		wsConn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		wsConn.Close()
+		return
+	}
+
+	ss.Set("ip", s.clientIP(r))
+}
@@ -1,8 +1,13 @@
 package ws_server
 
 import (
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/ws_session"
 	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/middleware/conn_limiter"
 	"net/http"
+	"time"
 )
 
 type HandlerFunc struct {
@@ -11,16 +16,59 @@ type HandlerFunc struct {
 	methods string
 }
 
+// MessageTypeFn 根据握手请求选择会话发送消息的帧类型
+type MessageTypeFn func(r *http.Request) int
+
 type WsOption struct {
 	addr string
 	pem  string
 	key  string
 
+	wsPath     string
+	healthPath string
+
 	https bool
 
 	handler      http.Handler
 	handlerFuncs []HandlerFunc
-	method       iface.IWsSessionMethod
+	method       iface.ISessionMethod
+	codec        iface.ICodec
+
+	shutdownMsg []byte
+
+	resumeWindow time.Duration
+	resumeSize   int
+
+	readBufferSize  int
+	writeBufferSize int
+	readLimit       int64
+
+	compress          bool
+	compressLevel     int
+	compressThreshold int
+
+	messageTypeFn MessageTypeFn
+
+	dispatchMode ws_session.DispatchMode
+	poolAssign   ws_session.PoolAssignFn
+
+	authenticator Authenticator
+	allowOrigins  []string
+	subprotocols  []string
+
+	trustedProxies []string
+
+	limiter *conn_limiter.ConnLimiter
+
+	cipherSuite frame_cipher.SUITE
+
+	queueSize   int
+	sendPolicy  enums.SEND_POLICY
+	sendTimeout time.Duration
+
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	pingInterval      time.Duration
 }
 
 type Option func(opts *WsOption)
@@ -28,6 +76,12 @@ type Option func(opts *WsOption)
 func NewWsOption() *WsOption {
 	o := &WsOption{
 		handlerFuncs: make([]HandlerFunc, 0),
+
+		wsPath: "/ws",
+
+		readBufferSize:  1024,
+		writeBufferSize: 1024,
+		readLimit:       enums.CONN_READ_LIMIT,
 	}
 
 	return o
@@ -57,20 +111,167 @@ func WithHttps(https bool) Option {
 	}
 }
 
+// WithWsPath websocket 握手路径, 默认 /ws
+func WithWsPath(path string) Option {
+	return func(opts *WsOption) {
+		opts.wsPath = path
+	}
+}
+
+// WithHealthPath 健康检查路径, 为空时不注册
+func WithHealthPath(path string) Option {
+	return func(opts *WsOption) {
+		opts.healthPath = path
+	}
+}
+
+// WithWsFunc 使用自定义 handler, 需自行挂载 WsServer 处理握手
 func WithWsFunc(handler http.Handler) Option {
 	return func(opts *WsOption) {
 		opts.handler = handler
 	}
 }
 
-func WithMethod(m iface.IWsSessionMethod) Option {
+func WithMethod(m iface.ISessionMethod) Option {
 	return func(opts *WsOption) {
 		opts.method = m
 	}
 }
 
+// WithCodec 会话的帧格式, 默认使用 codec.Get()
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *WsOption) {
+		opts.codec = c
+	}
+}
+
 func WithHandlerFunc(path string, fn func(http.ResponseWriter, *http.Request), methods string) Option {
 	return func(opts *WsOption) {
 		opts.handlerFuncs = append(opts.handlerFuncs, HandlerFunc{path, fn, methods})
 	}
 }
+
+// WithShutdownMsg 关服时发送给所有会话的消息
+func WithShutdownMsg(msg []byte) Option {
+	return func(opts *WsOption) {
+		opts.shutdownMsg = msg
+	}
+}
+
+// WithResume 开启断线重连, 客户端通过 resume_token/resume_seq 参数在 window 内接回原会话
+func WithResume(window time.Duration, size int) Option {
+	return func(opts *WsOption) {
+		opts.resumeWindow = window
+		opts.resumeSize = size
+	}
+}
+
+// WithHeartbeat 每隔 interval 检查一次, 超过 timeout 未收到心跳的会话将被关闭
+func WithHeartbeat(interval, timeout time.Duration) Option {
+	return func(opts *WsOption) {
+		opts.heartbeatInterval = interval
+		opts.heartbeatTimeout = timeout
+	}
+}
+
+// WithPing 服务器每隔 d 发送 ping, 收到 pong 时自动刷新心跳
+func WithPing(d time.Duration) Option {
+	return func(opts *WsOption) {
+		opts.pingInterval = d
+	}
+}
+
+// WithQueueSize 每个会话的发送队列长度
+func WithQueueSize(n int) Option {
+	return func(opts *WsOption) {
+		opts.queueSize = n
+	}
+}
+
+// WithSendPolicy 会话发送队列满时的处理方式, timeout 仅用于 SEND_POLICY_BLOCK
+func WithSendPolicy(policy enums.SEND_POLICY, timeout time.Duration) Option {
+	return func(opts *WsOption) {
+		opts.sendPolicy = policy
+		opts.sendTimeout = timeout
+	}
+}
+
+func WithBufferSize(read, write int) Option {
+	return func(opts *WsOption) {
+		opts.readBufferSize = read
+		opts.writeBufferSize = write
+	}
+}
+
+// WithReadLimit 客户端单条消息的最大长度, 超过时断开连接
+func WithReadLimit(n int64) Option {
+	return func(opts *WsOption) {
+		opts.readLimit = n
+	}
+}
+
+// WithCompression 开启 permessage-deflate, 小于 threshold 字节的消息不压缩
+func WithCompression(level, threshold int) Option {
+	return func(opts *WsOption) {
+		opts.compress = true
+		opts.compressLevel = level
+		opts.compressThreshold = threshold
+	}
+}
+
+// WithMessageTypeFn 按连接选择文本帧或二进制帧, 默认二进制帧
+func WithMessageTypeFn(fn MessageTypeFn) Option {
+	return func(opts *WsOption) {
+		opts.messageTypeFn = fn
+	}
+}
+
+// WithAuthenticator 升级前校验握手请求
+func WithAuthenticator(a Authenticator) Option {
+	return func(opts *WsOption) {
+		opts.authenticator = a
+	}
+}
+
+// WithAllowOrigins 允许的 Origin, 如 https://example.com、example.com、*.example.com
+func WithAllowOrigins(origins ...string) Option {
+	return func(opts *WsOption) {
+		opts.allowOrigins = append(opts.allowOrigins, origins...)
+	}
+}
+
+// WithTrustedProxies 可信代理的 IP 或 CIDR, 仅当对端在列表中时才使用 X-Real-IP/X-Forwarded-For 获取客户端 IP
+func WithTrustedProxies(proxies ...string) Option {
+	return func(opts *WsOption) {
+		opts.trustedProxies = append(opts.trustedProxies, proxies...)
+	}
+}
+
+// WithSubprotocols 服务器支持的子协议, 通过子协议传 token 时需设置
+func WithSubprotocols(protocols ...string) Option {
+	return func(opts *WsOption) {
+		opts.subprotocols = append(opts.subprotocols, protocols...)
+	}
+}
+
+// WithDispatch 会话收到消息后调用 Recv 回调的方式, 如 WithDispatch(ws_session.DISPATCH_POOL, worker_pool.AssignSessionTask)
+func WithDispatch(mode ws_session.DispatchMode, assign ws_session.PoolAssignFn) Option {
+	return func(opts *WsOption) {
+		opts.dispatchMode = mode
+		opts.poolAssign = assign
+	}
+}
+
+// WithConnLimiter 按 IP 限制连接数、新建连接速率和消息速率
+func WithConnLimiter(l *conn_limiter.ConnLimiter) Option {
+	return func(opts *WsOption) {
+		opts.limiter = l
+	}
+}
+
+// WithCipher 开启帧加密, 连接建立后客户端需先完成 frame_cipher 握手
+func WithCipher(suite frame_cipher.SUITE) Option {
+	return func(opts *WsOption) {
+		opts.cipherSuite = suite
+	}
+}
@@ -2,20 +2,24 @@ package ws_server
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"github.com/PaulSonOfLars/gotgbot/v2"
+	"errors"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
 	"github.com/v587-zyf/gc/gcnet/ws_session"
-	"github.com/v587-zyf/gc/gcnet/ws_session_mgr"
+	"github.com/v587-zyf/gc/iface"
 	"github.com/v587-zyf/gc/log"
-	"github.com/v587-zyf/gc/telegram/go_tg_bot"
-	"github.com/v587-zyf/gc/utils"
 	"go.uber.org/zap"
-	"io"
 	"kernel/tools"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
 type WsServer struct {
@@ -25,6 +29,11 @@ type WsServer struct {
 	cancel context.CancelFunc
 
 	upGrader *websocket.Upgrader
+	server   *http.Server
+
+	trusted []*net.IPNet
+
+	sessions sync.Map // 本服务器创建的会话, 关服时只关闭这些
 }
 
 func NewWsServer() *WsServer {
@@ -41,13 +50,21 @@ func (s *WsServer) Init(ctx context.Context, option ...Option) (err error) {
 	for _, opt := range option {
 		opt(s.options)
 	}
+	if s.trusted, err = parseTrusted(s.options.trustedProxies); err != nil {
+		log.Error("ws_server parse trusted proxies err", zap.Error(err))
+		return
+	}
+	if s.options.heartbeatInterval > 0 {
+		// 心跳检查由所有服务器共同持有, 本服务器关闭后只释放自己的引用
+		context.AfterFunc(s.ctx, session_mgr.GetSessionMgr().Sweep(s.options.heartbeatInterval))
+	}
 
 	s.upGrader = &websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+		ReadBufferSize:    s.options.readBufferSize,
+		WriteBufferSize:   s.options.writeBufferSize,
+		EnableCompression: s.options.compress,
+		Subprotocols:      s.options.subprotocols,
+		CheckOrigin:       s.checkOrigin,
 	}
 
 	return nil
@@ -55,34 +72,54 @@ func (s *WsServer) Init(ctx context.Context, option ...Option) (err error) {
 
 func (s *WsServer) Start() {
 	go tools.GoSafe("ws_server start loop", func() {
-		ws_session_mgr.GetSessionMgr().Start()
+		session_mgr.GetSessionMgr().Start()
 	})
 
 	var err error
 
 	if s.options.handler == nil {
 		r := mux.NewRouter()
-		r.HandleFunc("/api/test", s.test).Methods("GET")
-		r.HandleFunc("/api/webHook", s.webHook).Methods("POST")
-		r.HandleFunc("/ws", s.wsHandle).Methods("GET")
-		s.options.handler = r
-	}
-	if len(s.options.handlerFuncs) > 0 {
+		r.Handle(s.options.wsPath, s).Methods("GET", "OPTIONS")
+		if s.options.healthPath != "" {
+			r.HandleFunc(s.options.healthPath, s.health).Methods("GET")
+		}
 		for _, v := range s.options.handlerFuncs {
-			s.options.handler.(*mux.Router).HandleFunc(v.path, v.fn).Methods(v.methods)
+			r.HandleFunc(v.path, v.fn).Methods(v.methods)
+		}
+		s.options.handler = r
+	} else if len(s.options.handlerFuncs) > 0 {
+		if r, ok := s.options.handler.(*mux.Router); ok {
+			for _, v := range s.options.handlerFuncs {
+				r.HandleFunc(v.path, v.fn).Methods(v.methods)
+			}
+		} else {
+			log.Warn("ws_server handler funcs ignored, handler is not *mux.Router")
 		}
 	}
 
+	s.server = &http.Server{
+		Addr:    s.options.addr,
+		Handler: s.options.handler,
+	}
 	if s.options.https {
-		err = http.ListenAndServeTLS(s.options.addr, s.options.pem, s.options.key, s.options.handler)
+		err = s.server.ListenAndServeTLS(s.options.pem, s.options.key)
 	} else {
-		err = http.ListenAndServe(s.options.addr, s.options.handler)
+		err = s.server.ListenAndServe()
 	}
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		panic(err)
 	}
 }
 
+// ServeHTTP 处理 websocket 握手, 使用自定义 handler 时可将 WsServer 挂载到任意路由
+func (s *WsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.wsHandle(w, r)
+}
+
+func (s *WsServer) health(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *WsServer) wsHandle(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
@@ -93,51 +130,226 @@ func (s *WsServer) wsHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := s.clientIP(r)
+
+	limiter := s.options.limiter
+	if token := r.URL.Query().Get(RESUME_TOKEN_PARAM); token != "" {
+		if limiter != nil && !limiter.AllowConn(ip) {
+			http.Error(w, errcode.ERR_NET_RATE_LIMIT.Error(), http.StatusTooManyRequests)
+			return
+		}
+		s.resumeHandle(w, r, token)
+		return
+	}
+
+	if limiter != nil && !limiter.Accept(ip) {
+		http.Error(w, errcode.ERR_NET_RATE_LIMIT.Error(), http.StatusTooManyRequests)
+		return
+	}
+	release := func() {
+		if limiter != nil {
+			limiter.Release(ip)
+		}
+	}
+
+	var (
+		userID uint64
+		err    error
+	)
+	if s.options.authenticator != nil {
+		if userID, err = s.options.authenticator.Authenticate(r); err != nil {
+			log.Warn("ws_server auth err", zap.String("addr", r.RemoteAddr), zap.Error(err))
+			http.Error(w, err.Error(), authStatus(err))
+			release()
+			return
+		}
+	}
+
 	wsConn, err := s.upGrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Error("webSocket upgrade err:", zap.Error(err))
+		release()
+		return
+	}
+	if s.options.readLimit > 0 {
+		wsConn.SetReadLimit(s.options.readLimit)
+	}
+	cph, err := s.handshake(wsConn)
+	if err != nil {
+		log.Warn("ws_server handshake err", zap.String("addr", r.RemoteAddr), zap.Error(err))
+		wsConn.Close()
+		release()
 		return
 	}
 
-	ss := ws_session.NewSession(context.Background(), wsConn)
+	opts := []ws_session.Option{
+		ws_session.WithCodec(s.options.codec),
+		ws_session.WithHeartbeatTimeout(s.options.heartbeatTimeout),
+		ws_session.WithPing(s.options.pingInterval),
+		ws_session.WithQueueSize(s.options.queueSize),
+		ws_session.WithSendPolicy(s.options.sendPolicy, s.options.sendTimeout),
+		ws_session.WithDispatch(s.options.dispatchMode, s.options.poolAssign),
+	}
+	if s.options.compress {
+		opts = append(opts, ws_session.WithCompression(s.options.compressLevel, s.options.compressThreshold))
+	}
+	if s.options.messageTypeFn != nil {
+		opts = append(opts, ws_session.WithMessageType(s.options.messageTypeFn(r)))
+	}
+	if s.options.resumeWindow > 0 {
+		opts = append(opts, ws_session.WithResume(s.options.resumeWindow, s.options.resumeSize))
+	}
+	if cph != nil {
+		opts = append(opts, ws_session.WithCipher(cph))
+	}
+	if limiter != nil {
+		opts = append(opts, ws_session.WithRecvFilter(s.recvFilter))
+	}
+
+	ss := ws_session.NewSession(context.Background(), wsConn, opts...)
+	ss.Set("ip", ip)
+	ss.Hooks().OnStop("ws_server_sessions", func(iface.ISession) {
+		s.sessions.Delete(ss)
+	})
+	if limiter != nil {
+		ss.Hooks().OnStop("conn_limiter", func(iface.ISession) {
+			limiter.Release(ip)
+		})
+	}
 	ss.Hooks().OnMethod(s.options.method)
-	ws_session_mgr.GetSessionMgr().RegisterCh <- ss
+	s.sessions.Store(ss, struct{}{})
+	if userID != 0 {
+		// 直接加入管理器, 保证 Login 时会话已注册
+		ss.SetID(userID)
+		session_mgr.GetSessionMgr().AllAdd(ss)
+		ss.Login()
+	} else {
+		session_mgr.GetSessionMgr().RegisterCh <- ss
+	}
 	ss.Start()
+}
 
-	ip, err := utils.GetHttpIP(r)
+// clientIP 连接的对端 IP, 对端为可信代理时才使用转发头中的客户端 IP
+func (s *WsServer) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		fmt.Println("get ip err:", err)
-		return
+		ip = r.RemoteAddr
 	}
-	ss.Set("ip", ip)
+	if !s.isTrusted(ip) {
+		return ip
+	}
+
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(real) != nil {
+		return real
+	}
+	// 从右往左跳过可信代理, 第一个不可信的地址即客户端 IP
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !s.isTrusted(hop) {
+			break
+		}
+	}
+
+	return ip
 }
 
-func (s *WsServer) webHook(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
-		return
+func (s *WsServer) isTrusted(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, n := range s.trusted {
+		if n.Contains(addr) {
+			return true
+		}
 	}
 
-	var update gotgbot.Update
-	if err = json.Unmarshal(body, &update); err != nil {
-		http.Error(w, "Error parsing JSON", http.StatusBadRequest)
-		return
+	return false
+}
+
+// parseTrusted 解析可信代理列表, 单个 IP 视为 /32 或 /128
+func parseTrusted(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, errors.New("invalid trusted proxy: " + p)
+			}
+			if ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
 	}
 
-	if err = go_tg_bot.ProcessUpdate(&update); err != nil {
-		log.Error("tg bot process update err:", zap.Error(err))
-		http.Error(w, "Error process update", http.StatusBadRequest)
-		return
+	return nets, nil
+}
+
+// handshake 开启加密时在新连接上完成密钥交换, 未开启返回 nil
+func (s *WsServer) handshake(wsConn *websocket.Conn) (*frame_cipher.Cipher, error) {
+	if s.options.cipherSuite == frame_cipher.SUITE_NONE {
+		return nil, nil
 	}
 
-	w.WriteHeader(http.StatusOK)
+	wsConn.SetReadDeadline(time.Now().Add(enums.CONN_HANDSHAKE_TIMEOUT))
+	defer wsConn.SetReadDeadline(time.Time{})
+
+	return frame_cipher.ServerHandshake(frame_cipher.NewWsConn(wsConn, websocket.BinaryMessage), s.codec(), s.options.cipherSuite)
 }
 
-func (s *WsServer) test(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
+// recvFilter 按 msgID 对会话限流
+func (s *WsServer) recvFilter(ss iface.ISession, data []byte) bool {
+	var frame iface.MessageFrame
+	if err := s.codec().DecodeHeader(data, &frame); err != nil {
+		return true
+	}
+
+	return s.options.limiter.AllowMsg(ss, frame.MsgID)
+}
+
+func (s *WsServer) codec() iface.ICodec {
+	if s.options.codec != nil {
+		return s.options.codec
+	}
+	return codec.Get()
 }
 
 func (s *WsServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), enums.SERVER_SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	s.Shutdown(ctx)
+}
+
+// Shutdown 停止接受新连接, 通知所有会话关服并在 ctx 结束前发送完队列中的消息
+func (s *WsServer) Shutdown(ctx context.Context) (err error) {
+	if s.server != nil {
+		if err = s.server.Shutdown(ctx); err != nil {
+			log.Warn("ws_server http shutdown err", zap.Error(err))
+		}
+	}
+
+	if err = session_mgr.GetSessionMgr().ShutdownFunc(ctx, s.options.shutdownMsg, s.owns); err != nil {
+		log.Warn("ws_server shutdown err", zap.Error(err))
+	}
+	s.cancel()
+
+	return
+}
 
+func (s *WsServer) owns(ss iface.ISession) bool {
+	_, ok := s.sessions.Load(ss)
+	return ok
 }
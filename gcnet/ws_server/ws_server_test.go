@@ -0,0 +1,63 @@
+package ws_server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"github.com/v587-zyf/gc/middleware/conn_limiter"
+	"sync"
+	"testing"
+)
+
+// fixedCodec 解析出的 msgID 固定为 42, 用于确认限流使用配置的 codec
+type fixedCodec struct {
+	iface.ICodec
+}
+
+func (c fixedCodec) DecodeHeader(src []byte, frame *iface.MessageFrame) error {
+	if err := c.ICodec.DecodeHeader(src, frame); err != nil {
+		return err
+	}
+	frame.MsgID = 42
+	return nil
+}
+
+type filterSession struct {
+	iface.ISession
+	cache sync.Map
+}
+
+func (s *filterSession) GetID() uint64 { return 1 }
+
+func (s *filterSession) Get(key string) (any, bool) { return s.cache.Load(key) }
+
+func (s *filterSession) Set(key string, value any) { s.cache.Store(key, value) }
+
+func TestRecvFilterCodec(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	l := conn_limiter.NewConnLimiter()
+	as.NoError(l.Init(context.Background(),
+		conn_limiter.WithMsgRate("fixed", 1, 1),
+		conn_limiter.WithClassFn(func(msgID uint16) string {
+			if msgID == 42 {
+				return "fixed"
+			}
+			return conn_limiter.MSG_CLASS_DEFAULT
+		}),
+	))
+	defer l.Stop()
+
+	s := NewWsServer()
+	as.NoError(s.Init(context.Background(), WithConnLimiter(l), WithCodec(fixedCodec{codec.Get()})))
+
+	ss := &filterSession{}
+	data := codec.Pack(1, 0, 0, nil)
+	as.True(s.recvFilter(ss, data))
+	as.False(s.recvFilter(ss, data))
+}
@@ -0,0 +1,137 @@
+package ws_session
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/iface"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+type pendingCalls struct {
+	mu      sync.Mutex
+	seq     uint32
+	pending map[uint32]chan []byte
+	closed  bool
+}
+
+func newPendingCalls() *pendingCalls {
+	return &pendingCalls{
+		pending: make(map[uint32]chan []byte),
+	}
+}
+
+func (p *pendingCalls) add() (uint32, chan []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return 0, nil, errcode.ERR_NET_SESSION_CLOSED
+	}
+
+	for {
+		p.seq++
+		tag := (p.seq & ^enums.MSG_CALL_TAG_FLAG) | enums.MSG_CALL_TAG_FLAG
+		if _, ok := p.pending[tag]; !ok {
+			ch := make(chan []byte, 1)
+			p.pending[tag] = ch
+			return tag, ch, nil
+		}
+	}
+}
+
+func (p *pendingCalls) del(tag uint32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.pending, tag)
+}
+
+// done 将回复交给等待的调用方, tag 不属于挂起的调用时返回 false
+func (p *pendingCalls) done(tag uint32, data []byte) bool {
+	if tag&enums.MSG_CALL_TAG_FLAG == 0 {
+		return false
+	}
+
+	p.mu.Lock()
+	ch, ok := p.pending[tag]
+	delete(p.pending, tag)
+	p.mu.Unlock()
+
+	if ok {
+		ch <- data
+	}
+	return ok
+}
+
+func (p *pendingCalls) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for tag, ch := range p.pending {
+		close(ch)
+		delete(p.pending, tag)
+	}
+}
+
+// Request 向客户端发送请求并等待相同 Tag 的回复, 返回回复的包体
+func (s *Session) Request(ctx context.Context, msgID uint16, req iface.IProtoMessage) ([]byte, error) {
+	tag, ch, err := s.calls.add()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := codec.PackProtoWith(s.options.codec, msgID, tag, s.id, req)
+	if err != nil {
+		s.calls.del(tag)
+		return nil, err
+	}
+	if err = s.SendMsg(func(args ...any) ([]byte, error) {
+		return data, nil
+	}); err != nil {
+		s.calls.del(tag)
+		return nil, err
+	}
+
+	select {
+	case reply, ok := <-ch:
+		if !ok {
+			return nil, errcode.ERR_NET_SESSION_CLOSED
+		}
+		_, body, err := codec.UnpackWith(s.options.codec, reply)
+		return body, err
+	case <-ctx.Done():
+		s.calls.del(tag)
+		return nil, ctx.Err()
+	case <-s.closeCh:
+		// 等待会话关闭而不是当前连接断开, 挂起等待重连的会话中调用继续有效
+		s.calls.del(tag)
+		return nil, errcode.ERR_NET_SESSION_CLOSED
+	}
+}
+
+// CallProto 向客户端发送请求并把回复解析为 *T
+func CallProto[T any, PT interface {
+	*T
+	proto.Message
+}](ctx context.Context, ss iface.ISession, msgID uint16, req proto.Message) (PT, error) {
+	caller, ok := ss.(iface.ISessionCaller)
+	if !ok {
+		return nil, errcode.ERR_SERVER_INTERNAL
+	}
+
+	body, err := caller.Request(ctx, msgID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := PT(new(T))
+	if err = proto.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package ws_session
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"testing"
+)
+
+func TestPendingCalls(t *testing.T) {
+	var as = assert.New(t)
+
+	p := newPendingCalls()
+
+	tag, ch, err := p.add()
+	as.NoError(err)
+	as.NotZero(tag & enums.MSG_CALL_TAG_FLAG)
+
+	// 客户端请求的 Tag 不会被当作回复
+	as.False(p.done(tag&^enums.MSG_CALL_TAG_FLAG, nil))
+	as.True(p.done(tag, []byte("ok")))
+	as.Equal([]byte("ok"), <-ch)
+	as.False(p.done(tag, nil))
+
+	_, ch, err = p.add()
+	as.NoError(err)
+	p.close()
+	_, ok := <-ch
+	as.False(ok)
+
+	_, _, err = p.add()
+	as.Equal(errcode.ERR_NET_SESSION_CLOSED, err)
+}
@@ -0,0 +1,65 @@
+package ws_session
+
+import (
+	"github.com/v587-zyf/gc/buffer_pool"
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"runtime/debug"
+)
+
+// DispatchMode 收到消息后调用 Recv 回调的方式, Start/Stop 回调总是在会话内按顺序执行
+type DispatchMode int
+
+const (
+	DISPATCH_INLINE    DispatchMode = iota // 在读协程中按顺序执行
+	DISPATCH_GOROUTINE                     // 每个回调一个协程
+	DISPATCH_POOL                          // 交给 worker_pool 执行
+)
+
+// RecvFilter 消息分发前调用, 返回 false 丢弃该消息, 如按 IP 限流
+type RecvFilter func(ss iface.ISession, data []byte) bool
+
+// PoolAssignFn 向协程池提交任务, 如 worker_pool.AssignSessionTask
+type PoolAssignFn func(fn Recv, ss iface.ISession, data any) error
+
+func (s *Session) dispatch(message []byte) bool {
+	if s.options.dispatchMode == DISPATCH_INLINE {
+		buf := buffer_pool.GetBuffer()
+		if buf == nil {
+			log.Error("buffer_pool get err")
+			return false
+		}
+
+		buf.Data = ensureCapacity(buf.Data, len(message))
+		copy(buf.Data, message)
+
+		s.hooks.ExecuteRecv(s, buf.Data)
+		buffer_pool.Put(buf)
+		return true
+	}
+
+	// 异步执行时回调可能晚于下一次读取, 不能复用缓冲区
+	data := make([]byte, len(message))
+	copy(data, message)
+
+	for _, fn := range s.hooks.recvFns() {
+		switch s.options.dispatchMode {
+		case DISPATCH_GOROUTINE:
+			go func(fn Recv) {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Error("ExecuteRecv panic", zap.Any("r", r), zap.String("stack", string(debug.Stack())))
+					}
+				}()
+				fn(s, data)
+			}(fn)
+		case DISPATCH_POOL:
+			if err := s.options.poolAssign(fn, s, data); err != nil {
+				log.Warn("ws_session dispatch err", zap.Uint64("userID", s.id), zap.Error(err))
+			}
+		}
+	}
+
+	return true
+}
@@ -4,6 +4,6 @@ import (
 	"github.com/v587-zyf/gc/iface"
 )
 
-type Recv func(conn iface.IWsSession, data any)
+type Recv = iface.SessionRecv
 
-type Call func(ss iface.IWsSession)
+type Call func(ss iface.ISession)
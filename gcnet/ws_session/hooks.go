@@ -6,7 +6,7 @@ import (
 )
 
 type Hooks struct {
-	mu sync.Mutex
+	mu sync.RWMutex
 
 	startMethods map[string]Call
 	recvMethods  map[string]Recv
@@ -22,7 +22,8 @@ func NewHooks() *Hooks {
 }
 
 func (h *Hooks) OnStart(key string, fn Call) {
-	h.lock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
 	if fn != nil {
 		h.startMethods[key] = fn
@@ -30,7 +31,8 @@ func (h *Hooks) OnStart(key string, fn Call) {
 }
 
 func (h *Hooks) OnRecv(key string, fn Recv) {
-	h.lock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
 	if fn != nil {
 		h.recvMethods[key] = fn
@@ -38,25 +40,33 @@ func (h *Hooks) OnRecv(key string, fn Recv) {
 }
 
 func (h *Hooks) OnStop(key string, fn Call) {
-	h.lock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
 	if fn != nil {
 		h.stopMethods[key] = fn
 	}
 }
 
-func (h *Hooks) OnMethod(method iface.IWsSessionMethod) {
-	h.lock()
-
-	if method != nil {
-		h.OnStart(method.Name(), method.Start)
-		h.OnRecv(method.Name(), method.Recv)
-		h.OnStop(method.Name(), method.Stop)
+func (h *Hooks) OnMethod(method iface.ISessionMethod) {
+	if method == nil {
+		return
 	}
+
+	h.OnStart(method.Name(), method.Start)
+	h.OnRecv(method.Name(), method.Recv)
+	h.OnStop(method.Name(), method.Stop)
 }
 
 func (h *Hooks) OnHooks(hooks *Hooks) {
-	h.lock()
+	if hooks == nil {
+		return
+	}
+
+	hooks.mu.RLock()
+	defer hooks.mu.RUnlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
 	for key, fn := range hooks.startMethods {
 		h.startMethods[key] = fn
@@ -71,31 +81,42 @@ func (h *Hooks) OnHooks(hooks *Hooks) {
 	}
 }
 
-func (h *Hooks) ExecuteStart(ss iface.IWsSession) {
-	h.lock()
+func (h *Hooks) ExecuteStart(ss iface.ISession) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
 	for _, v := range h.startMethods {
 		v(ss)
 	}
 }
 
-func (h *Hooks) ExecuteRecv(ss iface.IWsSession, data []byte) {
-	h.lock()
+func (h *Hooks) ExecuteRecv(ss iface.ISession, data []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
 	for _, v := range h.recvMethods {
 		v(ss, data)
 	}
 }
 
-func (h *Hooks) ExecuteStop(ss iface.IWsSession) {
-	h.lock()
+func (h *Hooks) ExecuteStop(ss iface.ISession) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
 	for _, v := range h.stopMethods {
 		v(ss)
 	}
 }
 
-func (h *Hooks) lock() {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+// recvFns 返回当前的 Recv 回调, 异步分发时使用
+func (h *Hooks) recvFns() []Recv {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	fns := make([]Recv, 0, len(h.recvMethods))
+	for _, v := range h.recvMethods {
+		fns = append(fns, v)
+	}
+
+	return fns
 }
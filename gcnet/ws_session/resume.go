@@ -0,0 +1,193 @@
+package ws_session
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
+	"github.com/v587-zyf/gc/log"
+	"github.com/v587-zyf/gc/utils"
+	"go.uber.org/zap"
+	"kernel/tools"
+	"sync/atomic"
+	"time"
+)
+
+type resumeFrame struct {
+	seq  uint64
+	data []byte
+}
+
+// resumeState 断线重连状态
+// Login 后发给客户端的每一帧按顺序编号(从 1 开始)并保存在环形缓冲中,
+// 客户端重连时带上 token 和最后收到的序号, 服务器补发之后的帧
+type resumeState struct {
+	window time.Duration
+	token  string
+
+	seq  uint64
+	ring []resumeFrame
+	head int // 最旧一帧的位置
+	n    int
+
+	parked atomic.Bool
+	timer  *time.Timer
+}
+
+func newResumeState(window time.Duration, size int) *resumeState {
+	return &resumeState{
+		window: window,
+		ring:   make([]resumeFrame, size),
+	}
+}
+
+func (r *resumeState) push(data []byte) {
+	r.seq++
+
+	size := len(r.ring)
+	if r.n < size {
+		r.ring[(r.head+r.n)%size] = resumeFrame{seq: r.seq, data: data}
+		r.n++
+		return
+	}
+	r.ring[r.head] = resumeFrame{seq: r.seq, data: data}
+	r.head = (r.head + 1) % size
+}
+
+// since 返回 lastSeq 之后的帧, 缓冲中已没有需要的帧时返回 false
+func (r *resumeState) since(lastSeq uint64) ([][]byte, bool) {
+	if lastSeq > r.seq {
+		return nil, false
+	}
+	if r.n == 0 {
+		return nil, lastSeq == r.seq
+	}
+
+	oldest := r.ring[r.head].seq
+	if lastSeq+1 < oldest {
+		return nil, false
+	}
+
+	frames := make([][]byte, 0, r.seq-lastSeq)
+	for i := 0; i < r.n; i++ {
+		f := r.ring[(r.head+i)%len(r.ring)]
+		if f.seq > lastSeq {
+			frames = append(frames, f.data)
+		}
+	}
+
+	return frames, true
+}
+
+// ResumeToken 返回断线重连 token, 未开启或未登录时为空
+func (s *Session) ResumeToken() string {
+	if s.resume == nil {
+		return ""
+	}
+
+	s.resumeMu.Lock()
+	defer s.resumeMu.Unlock()
+
+	return s.resume.token
+}
+
+// issueResumeToken 首次 Login 时生成 token, 重复 Login 沿用原 token, 避免旧 token 残留在管理器中
+func (s *Session) issueResumeToken() {
+	if s.resume == nil || s.ResumeToken() != "" {
+		return
+	}
+
+	token, err := utils.GenerateSessionId()
+	if err != nil || token == "" {
+		log.Error("ws_session generate resume token err", zap.Uint64("userID", s.id), zap.Error(err))
+		return
+	}
+
+	s.resumeMu.Lock()
+	s.resume.token = token
+	s.resumeMu.Unlock()
+
+	s.Set(session_mgr.RESUME_TOKEN_KEY, token)
+}
+
+// record 记录发往客户端的帧, 返回 true 表示会话已挂起, 帧只进入缓冲
+func (s *Session) record(data []byte) bool {
+	if s.resume.token == "" {
+		return false
+	}
+
+	s.resume.push(data)
+	return s.resume.parked.Load()
+}
+
+// park 连接断开后挂起会话, 超过 window 未重连才真正关闭, 已关闭的会话不挂起
+func (s *Session) park() bool {
+	if s.resume == nil || s.closing.Load() || s.isClose.Load() {
+		return false
+	}
+
+	s.resumeMu.Lock()
+	defer s.resumeMu.Unlock()
+
+	if s.resume.token == "" || s.resume.parked.Load() || s.isClose.Load() {
+		return false
+	}
+
+	s.resume.parked.Store(true)
+	s.cur.Load().conn.Close()
+	s.resume.timer = time.AfterFunc(s.resume.window, func() {
+		s.Close()
+	})
+
+	return true
+}
+
+// Resume 将新的连接接到挂起的会话上, 并补发 lastSeq 之后的帧
+// 开启加密时 cph 为新连接握手得到的 Cipher, 缓冲的帧会用它重新加密
+func (s *Session) Resume(conn *websocket.Conn, lastSeq uint64, cph *frame_cipher.Cipher) error {
+	if s.resume == nil {
+		return errcode.ERR_NET_RESUME_FAILED
+	}
+
+	s.resumeMu.Lock()
+	defer s.resumeMu.Unlock()
+
+	// Close 先设置 isClose 再加锁停止定时器, 这里能看到并发的 Close
+	if !s.resume.parked.Load() || s.isClose.Load() {
+		return errcode.ERR_NET_RESUME_FAILED
+	}
+	frames, ok := s.resume.since(lastSeq)
+	if !ok {
+		return errcode.ERR_NET_RESUME_FAILED
+	}
+	// 定时器已触发说明会话正在关闭
+	if !s.resume.timer.Stop() {
+		return errcode.ERR_NET_RESUME_FAILED
+	}
+
+	s.Heartbeat()
+	s.cur.Store(s.newConnState(conn, cph))
+	s.resume.parked.Store(false)
+
+	// 断线前未发出的帧都在缓冲中, 丢弃后按序补发
+LOOP:
+	for {
+		select {
+		case <-s.outChan:
+		default:
+			break LOOP
+		}
+	}
+	for _, data := range frames {
+		s.outChan <- data
+	}
+
+	go tools.GoSafe("ws_session read pump", func() {
+		s.readPump()
+	})
+	go tools.GoSafe("ws_session io pump", func() {
+		s.IOPump()
+	})
+
+	return nil
+}
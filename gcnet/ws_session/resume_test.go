@@ -0,0 +1,148 @@
+package ws_session
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/log"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeStateSince(t *testing.T) {
+	var as = assert.New(t)
+
+	r := newResumeState(time.Second, 3)
+
+	frames, ok := r.since(0)
+	as.True(ok)
+	as.Empty(frames)
+
+	for _, b := range []byte{1, 2, 3, 4, 5} {
+		r.push([]byte{b})
+	}
+
+	frames, ok = r.since(2)
+	as.True(ok)
+	as.Equal([][]byte{{3}, {4}, {5}}, frames)
+
+	frames, ok = r.since(5)
+	as.True(ok)
+	as.Empty(frames)
+
+	// 帧 2 已被覆盖
+	_, ok = r.since(1)
+	as.False(ok)
+
+	_, ok = r.since(6)
+	as.False(ok)
+}
+
+func TestResume(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := (&websocket.Upgrader{}).Upgrade(w, r, nil); err == nil {
+			conns <- c
+		}
+	}))
+	defer srv.Close()
+	dial := func() *websocket.Conn {
+		c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		as.NoError(err)
+		return c
+	}
+	msg := func(b byte) func(args ...any) ([]byte, error) {
+		return func(args ...any) ([]byte, error) {
+			return []byte{b}, nil
+		}
+	}
+
+	client := dial()
+	s := NewSession(context.Background(), <-conns, WithResume(time.Second, 8))
+	s.issueResumeToken()
+	token := s.ResumeToken()
+	as.NotEmpty(token)
+	// 重复 Login 沿用原 token
+	s.issueResumeToken()
+	as.Equal(token, s.ResumeToken())
+	s.Start()
+	as.NoError(s.SendMsg(msg(1)))
+	_, data, err := client.ReadMessage()
+	as.NoError(err)
+	as.Equal([]byte{1}, data)
+
+	// 断线后挂起, 期间的消息进入缓冲, 挂起的会话不算心跳超时
+	client.Close()
+	as.Eventually(s.resume.parked.Load, time.Second, 10*time.Millisecond)
+	as.False(s.IsHeartbeatTimeout(time.Now().Add(time.Hour)))
+	as.NoError(s.SendMsg(msg(2)))
+
+	client = dial()
+	defer client.Close()
+	conn := <-conns
+	as.NoError(s.Resume(conn, 1, nil))
+	as.Equal(conn, s.GetConn())
+	_, data, err = client.ReadMessage()
+	as.NoError(err)
+	as.Equal([]byte{2}, data)
+
+	// 关闭后不再挂起, 发送返回错误
+	as.NoError(s.Close())
+	as.False(s.park())
+	as.ErrorIs(s.SendMsg(msg(3)), errcode.ERR_NET_SESSION_CLOSED)
+	as.ErrorIs(s.Resume(conn, 2, nil), errcode.ERR_NET_RESUME_FAILED)
+}
+
+func TestRequestParked(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := (&websocket.Upgrader{}).Upgrade(w, r, nil); err == nil {
+			conns <- c
+		}
+	}))
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	as.NoError(err)
+	s := NewSession(context.Background(), <-conns, WithResume(time.Second, 8))
+	s.issueResumeToken()
+	s.Start()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.Request(context.Background(), 1, wrapperspb.String("ping"))
+		errCh <- err
+	}()
+
+	// 断线挂起期间调用继续等待, 会话关闭后才失败
+	client.Close()
+	as.Eventually(s.resume.parked.Load, time.Second, 10*time.Millisecond)
+	select {
+	case err = <-errCh:
+		as.Fail("request failed while parked", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	as.NoError(s.Close())
+	select {
+	case err = <-errCh:
+		as.ErrorIs(err, errcode.ERR_NET_SESSION_CLOSED)
+	case <-time.After(time.Second):
+		as.Fail("request not released after close")
+	}
+}
@@ -3,49 +3,79 @@ package ws_session
 import (
 	"context"
 	"github.com/gorilla/websocket"
-	"github.com/v587-zyf/gc/buffer_pool"
 	"github.com/v587-zyf/gc/enums"
 	"github.com/v587-zyf/gc/errcode"
-	"github.com/v587-zyf/gc/gcnet/ws_session_mgr"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/gcnet/session_mgr"
 	"github.com/v587-zyf/gc/iface"
 	"github.com/v587-zyf/gc/log"
 	"go.uber.org/zap"
 	"kernel/tools"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type Session struct {
-	id   uint64
-	conn *websocket.Conn
+// connState 一次连接的状态, 断线重连时整体替换
+type connState struct {
+	conn   *websocket.Conn
+	cipher *frame_cipher.Cipher
 
 	ctx    context.Context
 	cancel context.CancelFunc
+}
+
+type Session struct {
+	options *SessionOption
+
+	id  uint64
+	cur atomic.Pointer[connState]
+
+	parent context.Context
 
 	hooks *Hooks
 	cache sync.Map
 	//method iface.IWsSessionMethod
 
 	outChan chan []byte
-	isClose bool
+	isClose atomic.Bool
+	closing atomic.Bool
+	closeMu sync.Mutex
+	closeCh chan struct{}
 
-	heartbeatTime time.Time
+	calls *pendingCalls
+
+	resume   *resumeState
+	resumeMu sync.Mutex
+
+	dropped     atomic.Uint64
+	messageType atomic.Int32
+
+	heartbeatTime atomic.Int64
 }
 
-func NewSession(ctx context.Context, conn *websocket.Conn) *Session {
-	ctx, cancel := context.WithCancel(ctx)
+func NewSession(ctx context.Context, conn *websocket.Conn, opts ...Option) *Session {
 	s := &Session{
-		ctx:    ctx,
-		cancel: cancel,
+		options: NewSessionOption(),
 
-		outChan: make(chan []byte, 1024),
+		parent: ctx,
 
-		hooks: NewHooks(),
+		hooks:   NewHooks(),
+		calls:   newPendingCalls(),
+		closeCh: make(chan struct{}),
+	}
 
-		heartbeatTime: time.Now(),
+	for _, opt := range opts {
+		opt(s.options)
+	}
+	s.cur.Store(s.newConnState(conn, s.options.cipher))
+	s.outChan = make(chan []byte, s.options.queueSize)
+	s.messageType.Store(int32(s.options.messageType))
+	s.Heartbeat()
+	if s.options.resumeWindow > 0 && s.options.resumeSize > 0 {
+		s.resume = newResumeState(s.options.resumeWindow, min(s.options.resumeSize, cap(s.outChan)))
 	}
-	s.conn = conn
 
 	return s
 }
@@ -87,32 +117,70 @@ func (s *Session) SetID(id uint64) {
 }
 
 func (s *Session) Close() error {
-	if !s.isClose {
-		s.isClose = true
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+
+	if !s.isClose.Load() {
+		s.isClose.Store(true)
+		if s.resume != nil {
+			s.resumeMu.Lock()
+			if s.resume.timer != nil {
+				s.resume.timer.Stop()
+			}
+			s.resumeMu.Unlock()
+		}
 
 		s.hooks.ExecuteStop(s)
+		s.calls.close()
 
-		close(s.outChan)
-
-		s.cancel()
-		s.conn.Close()
+		// 不关闭 outChan, 避免并发的 SendMsg 向已关闭的 channel 发送, IOPump 随 ctx 退出
+		c := s.cur.Load()
+		c.cancel()
+		c.conn.Close()
+		close(s.closeCh)
 
-		ws_session_mgr.GetSessionMgr().UnRegisterCh <- s
+		session_mgr.GetSessionMgr().UnRegisterCh <- s
 	}
 
 	return nil
 }
 
+// Shutdown 停止会话并在 ctx 结束前尽量发送完队列中的消息
+func (s *Session) Shutdown(ctx context.Context) error {
+	s.closing.Store(true)
+	if s.resume != nil && s.resume.parked.Load() {
+		return s.Close()
+	}
+	s.cur.Load().cancel()
+
+	select {
+	case <-s.closeCh:
+		return nil
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+func (s *Session) newConnState(conn *websocket.Conn, cph *frame_cipher.Cipher) *connState {
+	c := &connState{conn: conn, cipher: cph}
+	c.ctx, c.cancel = context.WithCancel(s.parent)
+
+	return c
+}
+
 func (s *Session) GetConn() iface.IConn {
-	return s.conn
+	return s.cur.Load().conn
 }
 
+// GetCtx 返回当前连接的 ctx, 断线重连后为新连接的 ctx
 func (s *Session) GetCtx() context.Context {
-	return s.ctx
+	return s.cur.Load().ctx
 }
 
 func (s *Session) Login() {
-	ws_session_mgr.GetSessionMgr().LoginCh <- s
+	s.issueResumeToken()
+	session_mgr.GetSessionMgr().LoginCh <- s
 }
 
 func (s *Session) DoSomething(fn func(args ...any) bool) bool {
@@ -122,39 +190,102 @@ func (s *Session) CheckSomething(fn func(args ...any) bool) bool {
 	return fn()
 }
 func (s *Session) Heartbeat() {
-	s.heartbeatTime = time.Now()
+	s.heartbeatTime.Store(time.Now().UnixNano())
 }
 
+// IsHeartbeatTimeout 挂起的会话由 park 的定时器关闭, 不算超时
 func (s *Session) IsHeartbeatTimeout(now time.Time) bool {
-	return now.After(s.heartbeatTime.Add(enums.HEARTBEAT_TIMEOUT))
+	if s.resume != nil && s.resume.parked.Load() {
+		return false
+	}
+
+	return now.After(time.Unix(0, s.heartbeatTime.Load()).Add(s.options.heartbeatTimeout))
 }
 
 func (s *Session) SendMsg(fn func(args ...any) ([]byte, error), args ...any) error {
-	if s.isClose {
-		return nil
+	if s.isClose.Load() {
+		return errcode.ERR_NET_SESSION_CLOSED
 	}
 	sendBytes, err := fn(args...)
 	if err != nil {
 		return err
 	}
 
-	for i := 0; i < 3; i++ {
+	if s.resume != nil {
+		// 记录和入队需保持顺序, 避免重连补发时乱序
+		s.resumeMu.Lock()
+		defer s.resumeMu.Unlock()
+
+		if s.record(sendBytes) {
+			return nil
+		}
+	}
+
+	return s.push(sendBytes)
+}
+
+// push 将消息放入发送队列, 队列满时按 sendPolicy 处理
+func (s *Session) push(data []byte) error {
+	select {
+	case s.outChan <- data:
+		return nil
+	default:
+	}
+
+	switch s.options.sendPolicy {
+	case enums.SEND_POLICY_DROP_OLDEST:
+		for {
+			select {
+			case <-s.outChan:
+				s.dropped.Add(1)
+			default:
+			}
+			select {
+			case s.outChan <- data:
+				return nil
+			default:
+			}
+		}
+	case enums.SEND_POLICY_DISCONNECT:
+		s.dropped.Add(1)
+		log.Warn("ws_session send queue full, disconnect", zap.Uint64("userID", s.id), zap.Int("queue", cap(s.outChan)))
+		go s.Close()
+		return errcode.ERR_NET_SESSION_CLOSED
+	case enums.SEND_POLICY_BLOCK:
+		timer := time.NewTimer(s.options.sendTimeout)
+		defer timer.Stop()
+
 		select {
-		case s.outChan <- sendBytes:
+		case s.outChan <- data:
 			return nil
-		default:
-			backoff := time.Duration(100) * time.Millisecond * time.Duration(2^i)
-			time.Sleep(backoff)
+		case <-timer.C:
+		case <-s.cur.Load().ctx.Done():
 		}
 	}
 
+	s.dropped.Add(1)
 	return errcode.ERR_NET_SEND_TIMEOUT
 }
 
+// Dropped 因发送队列满而丢弃的消息数
+func (s *Session) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
 func (s *Session) readPump() {
+	// 断线重连会替换 conn 和 ctx, 这里只处理本次连接
+	c := s.cur.Load()
+	conn, cancel, cph := c.conn, c.cancel, c.cipher
+	if s.options.pingInterval > 0 {
+		conn.SetPongHandler(func(string) error {
+			s.Heartbeat()
+			return nil
+		})
+	}
+
 LOOP:
 	for {
-		_, message, err := s.conn.ReadMessage()
+		_, message, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err,
 				websocket.CloseGoingAway,
@@ -170,21 +301,36 @@ LOOP:
 		}
 
 		if message != nil && len(message) > 0 {
-			buf := buffer_pool.GetBuffer()
-			if buf == nil {
-				log.Error("buffer_pool get err")
+			if cph != nil {
+				if message, err = cph.Open(message); err != nil {
+					log.Warn("ws_session decrypt err", zap.Uint64("userID", s.GetID()), zap.Error(err))
+					break LOOP
+				}
+			}
+			if s.isReply(message) {
+				continue
+			}
+			if s.options.recvFilter != nil && !s.options.recvFilter(s, message) {
+				continue
+			}
+
+			if !s.dispatch(message) {
 				break LOOP
 			}
+		}
+	}
 
-			buf.Data = ensureCapacity(buf.Data, len(message))
-			copy(buf.Data, message)
+	cancel()
+}
 
-			s.hooks.ExecuteRecv(s, buf.Data)
-			buffer_pool.Put(buf)
-		}
+// isReply 判断是否为 Request 的回复, 是则交给等待的调用方
+func (s *Session) isReply(message []byte) bool {
+	var frame iface.MessageFrame
+	if err := s.options.codec.DecodeHeader(message, &frame); err != nil {
+		return false
 	}
 
-	s.cancel()
+	return s.calls.done(frame.Tag, message)
 }
 
 func ensureCapacity(slice []byte, size int) []byte {
@@ -202,14 +348,36 @@ func (s *Session) IOPump() {
 	var (
 		err     error
 		backoff time.Duration
+
+		c    = s.cur.Load()
+		conn = c.conn
+		ctx  = c.ctx
+		cph  = c.cipher
+
+		pingC <-chan time.Time
 	)
+	if s.options.pingInterval > 0 {
+		ticker := time.NewTicker(s.options.pingInterval)
+		defer ticker.Stop()
+		pingC = ticker.C
+	}
+	if s.options.compressLevel != 0 {
+		conn.SetCompressionLevel(s.options.compressLevel)
+	}
 
 LOOP:
 	for {
 		select {
+		case <-pingC:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(enums.CONN_WRITE_WAIT_TIME)); err != nil {
+				break LOOP
+			}
 		case data := <-s.outChan:
+			if data = seal(cph, data); data == nil {
+				continue
+			}
 			for i := 0; i < 3; i++ {
-				if err = s.conn.WriteMessage(websocket.BinaryMessage, data); err == nil {
+				if err = s.write(conn, data); err == nil {
 					break
 				}
 				backoff = calculateBackoff(i)
@@ -218,12 +386,62 @@ LOOP:
 			if err != nil {
 				break LOOP
 			}
-		case <-s.ctx.Done():
+		case <-ctx.Done():
+			s.drain(conn, cph)
 			break LOOP
 		}
 	}
 
-	s.Close()
+	if !s.park() {
+		s.Close()
+	}
+}
+
+func (s *Session) drain(conn *websocket.Conn, cph *frame_cipher.Cipher) {
+	conn.SetWriteDeadline(time.Now().Add(enums.CONN_WRITE_WAIT_TIME))
+
+	for {
+		select {
+		case data := <-s.outChan:
+			if data = seal(cph, data); data == nil {
+				continue
+			}
+			if err := s.write(conn, data); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// SetMessageType 切换发送消息使用的帧类型, 如 JSON 客户端使用 websocket.TextMessage
+func (s *Session) SetMessageType(mt int) {
+	if mt == websocket.TextMessage || mt == websocket.BinaryMessage {
+		s.messageType.Store(int32(mt))
+	}
+}
+
+func (s *Session) write(conn *websocket.Conn, data []byte) error {
+	// 小消息压缩收益低, 不压缩
+	conn.EnableWriteCompression(len(data) >= s.options.compressThreshold)
+
+	return conn.WriteMessage(int(s.messageType.Load()), data)
+}
+
+// seal 开启加密时在写协程中加密, 重试时不能重复加密
+func seal(cph *frame_cipher.Cipher, data []byte) []byte {
+	if cph == nil {
+		return data
+	}
+
+	sealed, err := cph.Seal(data)
+	if err != nil {
+		log.Warn("ws_session encrypt err", zap.Error(err))
+		return nil
+	}
+
+	return sealed
 }
 
 func calculateBackoff(attempt int) time.Duration {
@@ -0,0 +1,144 @@
+package ws_session
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/gcnet/codec"
+	"github.com/v587-zyf/gc/gcnet/frame_cipher"
+	"github.com/v587-zyf/gc/iface"
+	"time"
+)
+
+type SessionOption struct {
+	codec iface.ICodec
+
+	queueSize   int
+	sendPolicy  enums.SEND_POLICY
+	sendTimeout time.Duration
+
+	heartbeatTimeout time.Duration
+	pingInterval     time.Duration
+
+	dispatchMode DispatchMode
+	poolAssign   PoolAssignFn
+	recvFilter   RecvFilter
+	cipher       *frame_cipher.Cipher
+
+	messageType       int
+	compressLevel     int
+	compressThreshold int
+
+	resumeWindow time.Duration
+	resumeSize   int
+}
+
+type Option func(opts *SessionOption)
+
+func NewSessionOption() *SessionOption {
+	o := &SessionOption{
+		codec: codec.Get(),
+
+		queueSize:   enums.SESSION_QUEUE_SIZE,
+		sendPolicy:  enums.SEND_POLICY_DROP_NEWEST,
+		sendTimeout: enums.CONN_WRITE_WAIT_TIME,
+
+		heartbeatTimeout: enums.HEARTBEAT_TIMEOUT,
+
+		messageType: websocket.BinaryMessage,
+	}
+
+	return o
+}
+
+func WithCodec(c iface.ICodec) Option {
+	return func(opts *SessionOption) {
+		if c != nil {
+			opts.codec = c
+		}
+	}
+}
+
+// WithResume 开启断线重连, 断线后保留会话 window 时长, 最多缓存 size 帧
+func WithResume(window time.Duration, size int) Option {
+	return func(opts *SessionOption) {
+		opts.resumeWindow = window
+		opts.resumeSize = size
+	}
+}
+
+// WithHeartbeatTimeout 超过 d 未收到心跳视为超时
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(opts *SessionOption) {
+		if d > 0 {
+			opts.heartbeatTimeout = d
+		}
+	}
+}
+
+// WithPing 服务器每隔 d 发送 ping, 收到 pong 时自动刷新心跳
+func WithPing(d time.Duration) Option {
+	return func(opts *SessionOption) {
+		opts.pingInterval = d
+	}
+}
+
+// WithQueueSize 发送队列长度
+func WithQueueSize(n int) Option {
+	return func(opts *SessionOption) {
+		if n > 0 {
+			opts.queueSize = n
+		}
+	}
+}
+
+// WithSendPolicy 发送队列满时的处理方式, timeout 仅用于 SEND_POLICY_BLOCK
+func WithSendPolicy(policy enums.SEND_POLICY, timeout time.Duration) Option {
+	return func(opts *SessionOption) {
+		opts.sendPolicy = policy
+		if timeout > 0 {
+			opts.sendTimeout = timeout
+		}
+	}
+}
+
+// WithMessageType 发送消息使用的帧类型, websocket.TextMessage 或 websocket.BinaryMessage
+func WithMessageType(mt int) Option {
+	return func(opts *SessionOption) {
+		if mt == websocket.TextMessage || mt == websocket.BinaryMessage {
+			opts.messageType = mt
+		}
+	}
+}
+
+// WithCompression 协商了 permessage-deflate 时按 level 压缩不小于 threshold 字节的消息
+func WithCompression(level, threshold int) Option {
+	return func(opts *SessionOption) {
+		opts.compressLevel = level
+		opts.compressThreshold = threshold
+	}
+}
+
+// WithDispatch 收到消息后调用 Recv 回调的方式, DISPATCH_POOL 需提供 assign
+func WithDispatch(mode DispatchMode, assign PoolAssignFn) Option {
+	return func(opts *SessionOption) {
+		if mode == DISPATCH_POOL && assign == nil {
+			return
+		}
+		opts.dispatchMode = mode
+		opts.poolAssign = assign
+	}
+}
+
+// WithRecvFilter 消息分发前的过滤函数
+func WithRecvFilter(fn RecvFilter) Option {
+	return func(opts *SessionOption) {
+		opts.recvFilter = fn
+	}
+}
+
+// WithCipher 使用握手得到的 Cipher 加解密收发的帧, 需使用 websocket.BinaryMessage
+func WithCipher(c *frame_cipher.Cipher) Option {
+	return func(opts *SessionOption) {
+		opts.cipher = c
+	}
+}
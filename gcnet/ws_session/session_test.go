@@ -0,0 +1,96 @@
+package ws_session
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/enums"
+	"github.com/v587-zyf/gc/errcode"
+	"github.com/v587-zyf/gc/iface"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatTimeout(t *testing.T) {
+	var as = assert.New(t)
+
+	s := NewSession(context.Background(), nil)
+	as.False(s.IsHeartbeatTimeout(time.Now()))
+	as.True(s.IsHeartbeatTimeout(time.Now().Add(enums.HEARTBEAT_TIMEOUT + time.Second)))
+
+	s = NewSession(context.Background(), nil, WithHeartbeatTimeout(time.Minute))
+	as.False(s.IsHeartbeatTimeout(time.Now().Add(enums.HEARTBEAT_TIMEOUT + time.Second)))
+	as.True(s.IsHeartbeatTimeout(time.Now().Add(2 * time.Minute)))
+}
+
+func TestSendPolicy(t *testing.T) {
+	var as = assert.New(t)
+
+	msg := func(b byte) func(args ...any) ([]byte, error) {
+		return func(args ...any) ([]byte, error) {
+			return []byte{b}, nil
+		}
+	}
+
+	s := NewSession(context.Background(), nil, WithQueueSize(2))
+	as.NoError(s.SendMsg(msg(1)))
+	as.NoError(s.SendMsg(msg(2)))
+	as.ErrorIs(s.SendMsg(msg(3)), errcode.ERR_NET_SEND_TIMEOUT)
+	as.Equal(uint64(1), s.Dropped())
+	as.Equal([]byte{1}, <-s.outChan)
+
+	s = NewSession(context.Background(), nil, WithQueueSize(2), WithSendPolicy(enums.SEND_POLICY_DROP_OLDEST, 0))
+	for b := byte(1); b <= 3; b++ {
+		as.NoError(s.SendMsg(msg(b)))
+	}
+	as.Equal(uint64(1), s.Dropped())
+	as.Equal([]byte{2}, <-s.outChan)
+	as.Equal([]byte{3}, <-s.outChan)
+
+	s = NewSession(context.Background(), nil, WithQueueSize(1), WithSendPolicy(enums.SEND_POLICY_BLOCK, 10*time.Millisecond))
+	as.NoError(s.SendMsg(msg(1)))
+	go func() {
+		time.Sleep(time.Millisecond)
+		<-s.outChan
+	}()
+	as.NoError(s.SendMsg(msg(2)))
+	as.ErrorIs(s.SendMsg(msg(3)), errcode.ERR_NET_SEND_TIMEOUT)
+	as.Equal(uint64(1), s.Dropped())
+}
+
+func TestDispatch(t *testing.T) {
+	var as = assert.New(t)
+
+	var tasks []any
+	assign := func(fn Recv, ss iface.ISession, data any) error {
+		tasks = append(tasks, data)
+		fn(ss, data)
+		return nil
+	}
+
+	recv := make(chan []byte, 1)
+	s := NewSession(context.Background(), nil, WithDispatch(DISPATCH_POOL, assign))
+	s.Hooks().OnRecv("test", func(ss iface.ISession, data any) {
+		recv <- data.([]byte)
+	})
+
+	msg := []byte{1, 2}
+	as.True(s.dispatch(msg))
+	as.Len(tasks, 1)
+	data := <-recv
+	as.Equal(msg, data)
+	// 异步分发时不复用读缓冲
+	msg[0] = 9
+	as.Equal(byte(1), data[0])
+
+	s = NewSession(context.Background(), nil, WithDispatch(DISPATCH_GOROUTINE, nil))
+	s.Hooks().OnRecv("test", func(ss iface.ISession, data any) {
+		recv <- data.([]byte)
+	})
+	as.True(s.dispatch([]byte{3}))
+	select {
+	case data = <-recv:
+		as.Equal([]byte{3}, data)
+	case <-time.After(time.Second):
+		t.Fatal("dispatch timeout")
+	}
+}
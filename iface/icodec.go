@@ -0,0 +1,9 @@
+package iface
+
+type ICodec interface {
+	HeaderSize() int
+	MaxPacketSize() int
+
+	EncodeHeader(dst []byte, frame *MessageFrame)
+	DecodeHeader(src []byte, frame *MessageFrame) error
+}
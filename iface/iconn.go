@@ -5,10 +5,3 @@ type IConn interface {
 	WriteMessage(messageType int, data []byte) error
 	Close() error
 }
-
-type IWsConnMethod interface {
-	Name() string
-	Start(ss IWsSession)
-	Recv(conn IWsSession, data any)
-	Stop(ss IWsSession)
-}
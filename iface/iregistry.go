@@ -0,0 +1,21 @@
+package iface
+
+import "context"
+
+// ServiceInstance 注册到服务发现的服务器实例
+type ServiceInstance struct {
+	Type int32             `json:"type"`
+	ID   uint64            `json:"id"`
+	Addr string            `json:"addr"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+type IRegistry interface {
+	// Register 注册实例并按 TTL 续约, ctx 结束或 Deregister 后停止续约
+	Register(ctx context.Context, ins *ServiceInstance) error
+	Deregister(ctx context.Context, ins *ServiceInstance) error
+
+	List(ctx context.Context, serverType int32) ([]*ServiceInstance, error)
+	// Watch 先发送当前列表, 之后列表变化时发送, ctx 结束后关闭
+	Watch(ctx context.Context, serverType int32) (<-chan []*ServiceInstance, error)
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 )
 
-type ITcpSession interface {
+// ISession tcp 和 ws 会话的公共部分, 业务逻辑只依赖 ISession 即可同时服务两种连接
+// 需要底层连接时断言为 ITcpSession 或 IWsSession 后调用 GetConn
+type ISession interface {
 	Set(key string, value any)
 	Get(key string) (any, bool)
 	Remove(key string)
@@ -17,7 +19,6 @@ type ITcpSession interface {
 	Start()
 	Close() error
 
-	GetConn() net.Conn
 	GetCtx() context.Context
 
 	SendMsg(fn func(args ...any) ([]byte, error), args ...any) error
@@ -28,37 +29,32 @@ type ITcpSession interface {
 	IsHeartbeatTimeout(now time.Time) bool
 }
 
-type IWsSession interface {
-	Set(key string, value any)
-	Get(key string) (any, bool)
-	Remove(key string)
+type ITcpSession interface {
+	ISession
 
-	GetID() uint64
-	SetID(id uint64)
+	GetConn() net.Conn
+}
 
-	Start()
-	Close() error
+type IWsSession interface {
+	ISession
 
 	GetConn() IConn
-	GetCtx() context.Context
+}
 
-	SendMsg(fn func(args ...any) ([]byte, error), args ...any) error
+// SessionRecv 会话收到消息的回调
+type SessionRecv func(ss ISession, data any)
 
-	DoSomething(fn func(args ...any) bool) bool
-	CheckSomething(fn func(args ...any) bool) bool
-
-	IsHeartbeatTimeout(now time.Time) bool
+type ISessionCaller interface {
+	Request(ctx context.Context, msgID uint16, req IProtoMessage) ([]byte, error)
 }
 
-type ITcpSessionMethod interface {
-	Name() string
-	Start(ss ITcpSession)
-	Recv(conn ITcpSession, data any)
-	Stop(ss ITcpSession)
+type ISessionShutdown interface {
+	Shutdown(ctx context.Context) error
 }
-type IWsSessionMethod interface {
+
+type ISessionMethod interface {
 	Name() string
-	Start(ss IWsSession)
-	Recv(conn IWsSession, data any)
-	Stop(ss IWsSession)
+	Start(ss ISession)
+	Recv(ss ISession, data any)
+	Stop(ss ISession)
 }
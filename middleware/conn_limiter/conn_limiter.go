@@ -0,0 +1,308 @@
+package conn_limiter
+
+import (
+	"context"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"kernel/tools"
+	"sync"
+	"time"
+)
+
+type ACTION int
+
+const (
+	ACTION_DROP       ACTION = iota // 丢弃消息或拒绝连接
+	ACTION_WARN                     // 记录日志后放行
+	ACTION_DISCONNECT               // 断开连接
+	ACTION_BAN                      // 断开连接并封禁 IP
+)
+
+type VIOLATION int
+
+const (
+	VIOLATION_CONN_MAX  VIOLATION = iota // 单 IP 并发连接数超限
+	VIOLATION_CONN_RATE                  // 单 IP 新建连接过快
+	VIOLATION_MSG_RATE                   // 会话消息过快
+)
+
+const (
+	MSG_CLASS_DEFAULT = "default"
+
+	BAN_DURATION   = 10 * time.Minute
+	SWEEP_INTERVAL = time.Minute
+
+	msgLimiterKey = "conn_limiter_msg"
+)
+
+// Session tcp_session 和 ws_session 的公共部分
+type Session interface {
+	GetID() uint64
+	Get(key string) (any, bool)
+	Set(key string, value any)
+	Close() error
+}
+
+type Violation struct {
+	Kind   VIOLATION
+	IP     string
+	UserID uint64
+	MsgID  uint16
+	Class  string
+}
+
+type ipState struct {
+	conns    int
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type ConnLimiter struct {
+	options *LimiterOption
+
+	mu  sync.Mutex
+	ips map[string]*ipState
+	ban map[string]time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewConnLimiter() *ConnLimiter {
+	return &ConnLimiter{
+		options: NewLimiterOption(),
+		ips:     make(map[string]*ipState),
+		ban:     make(map[string]time.Time),
+	}
+}
+
+func (l *ConnLimiter) Init(ctx context.Context, opts ...Option) (err error) {
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	for _, opt := range opts {
+		opt(l.options)
+	}
+
+	go tools.GoSafe("conn_limiter sweep", func() {
+		l.sweep()
+	})
+
+	return
+}
+
+func (l *ConnLimiter) Stop() {
+	l.cancel()
+}
+
+// Accept 新连接检查, 通过后计入 ip 的连接数, 连接关闭时需调用 Release
+func (l *ConnLimiter) Accept(ip string) bool {
+	if l.IsBanned(ip) {
+		return false
+	}
+
+	// 检查和计数在同一临界区内, 避免并发连接同时通过上限检查
+	l.mu.Lock()
+	st := l.state(ip)
+	var v *Violation
+	if st.limiter != nil && !st.limiter.Allow() {
+		v = &Violation{Kind: VIOLATION_CONN_RATE, IP: ip}
+	} else if l.options.maxConnsPerIP > 0 && st.conns >= l.options.maxConnsPerIP {
+		v = &Violation{Kind: VIOLATION_CONN_MAX, IP: ip}
+	}
+	st.conns++
+	l.mu.Unlock()
+
+	if v != nil && !l.violate(v, nil) {
+		l.Release(ip)
+		return false
+	}
+
+	return true
+}
+
+// AllowConn 只检查封禁和新建连接速率, 不计入连接数, 用于断线重连等复用已有会话的场景
+func (l *ConnLimiter) AllowConn(ip string) bool {
+	if l.IsBanned(ip) {
+		return false
+	}
+
+	l.mu.Lock()
+	st := l.state(ip)
+	ok := st.limiter == nil || st.limiter.Allow()
+	l.mu.Unlock()
+
+	if !ok {
+		return l.violate(&Violation{Kind: VIOLATION_CONN_RATE, IP: ip}, nil)
+	}
+
+	return true
+}
+
+func (l *ConnLimiter) Release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if st, ok := l.ips[ip]; ok && st.conns > 0 {
+		st.conns--
+		st.lastSeen = time.Now()
+	}
+}
+
+// ConnLen ip 当前的连接数
+func (l *ConnLimiter) ConnLen(ip string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if st, ok := l.ips[ip]; ok {
+		return st.conns
+	}
+	return 0
+}
+
+// state 需持有 mu
+func (l *ConnLimiter) state(ip string) *ipState {
+	st, ok := l.ips[ip]
+	if !ok {
+		st = &ipState{}
+		if l.options.connRate > 0 {
+			st.limiter = rate.NewLimiter(rate.Limit(l.options.connRate), l.options.connBurst)
+		}
+		l.ips[ip] = st
+	}
+	st.lastSeen = time.Now()
+
+	return st
+}
+
+// AllowMsg 会话收到消息时检查, 返回 false 时丢弃该消息
+func (l *ConnLimiter) AllowMsg(ss Session, msgID uint16) bool {
+	class := l.options.classFn(msgID)
+	r, ok := l.options.msgRates[class]
+	if !ok {
+		class = MSG_CLASS_DEFAULT
+		if r, ok = l.options.msgRates[class]; !ok {
+			return true
+		}
+	}
+
+	var limiters *sync.Map
+	if v, ok := ss.Get(msgLimiterKey); ok {
+		limiters = v.(*sync.Map)
+	} else {
+		limiters = new(sync.Map)
+		ss.Set(msgLimiterKey, limiters)
+	}
+
+	v, _ := limiters.LoadOrStore(class, rate.NewLimiter(rate.Limit(r.limit), r.burst))
+	if v.(*rate.Limiter).Allow() {
+		return true
+	}
+
+	ip, _ := ss.Get("ip")
+	ipStr, _ := ip.(string)
+
+	return l.violate(&Violation{Kind: VIOLATION_MSG_RATE, IP: ipStr, UserID: ss.GetID(), MsgID: msgID, Class: class}, ss)
+}
+
+// violate 执行处理方式, 返回是否放行
+func (l *ConnLimiter) violate(v *Violation, ss Session) bool {
+	action := l.options.action
+	if l.options.actionFn != nil {
+		action = l.options.actionFn(v)
+	}
+
+	switch action {
+	case ACTION_WARN:
+		log.Warn("conn_limiter limited", zap.Int("kind", int(v.Kind)), zap.String("ip", v.IP),
+			zap.Uint64("userID", v.UserID), zap.Uint16("msgID", v.MsgID))
+		return true
+	case ACTION_DISCONNECT:
+		if ss != nil {
+			ss.Close()
+		}
+	case ACTION_BAN:
+		if v.IP != "" {
+			l.Ban(v.IP, l.options.banDuration)
+		}
+		if ss != nil {
+			ss.Close()
+		}
+		log.Warn("conn_limiter ban", zap.Int("kind", int(v.Kind)), zap.String("ip", v.IP),
+			zap.Uint64("userID", v.UserID), zap.Duration("duration", l.options.banDuration))
+	}
+
+	return false
+}
+
+// Ban 封禁 ip, d 之后自动解封
+func (l *ConnLimiter) Ban(ip string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ban[ip] = time.Now().Add(d)
+}
+
+func (l *ConnLimiter) Unban(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.ban, ip)
+}
+
+func (l *ConnLimiter) IsBanned(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expire, ok := l.ban[ip]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expire) {
+		delete(l.ban, ip)
+		return false
+	}
+
+	return true
+}
+
+// BanList 当前封禁的 ip 及解封时间
+func (l *ConnLimiter) BanList() map[string]time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	list := make(map[string]time.Time, len(l.ban))
+	for ip, expire := range l.ban {
+		if now.Before(expire) {
+			list[ip] = expire
+		}
+	}
+
+	return list
+}
+
+// sweep 定期清理过期封禁和已无连接的 ip
+func (l *ConnLimiter) sweep() {
+	ticker := time.NewTicker(l.options.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			for ip, expire := range l.ban {
+				if now.After(expire) {
+					delete(l.ban, ip)
+				}
+			}
+			for ip, st := range l.ips {
+				if st.conns == 0 && now.Sub(st.lastSeen) > l.options.sweepInterval {
+					delete(l.ips, ip)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package conn_limiter
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/v587-zyf/gc/log"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type testSession struct {
+	cache  sync.Map
+	closed bool
+}
+
+func (s *testSession) GetID() uint64 { return 1 }
+
+func (s *testSession) Get(key string) (any, bool) { return s.cache.Load(key) }
+
+func (s *testSession) Set(key string, value any) { s.cache.Store(key, value) }
+
+func (s *testSession) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestAccept(t *testing.T) {
+	var as = assert.New(t)
+
+	l := NewConnLimiter()
+	as.NoError(l.Init(context.Background(), WithMaxConnsPerIP(2), WithConnRate(1, 3)))
+	defer l.Stop()
+
+	as.True(l.Accept("1.1.1.1"))
+	as.True(l.Accept("1.1.1.1"))
+	as.False(l.Accept("1.1.1.1"))
+	as.True(l.Accept("2.2.2.2"))
+	as.Equal(2, l.ConnLen("1.1.1.1"))
+
+	// 令牌已用完, 释放连接后仍受新建速率限制
+	l.Release("1.1.1.1")
+	as.False(l.Accept("1.1.1.1"))
+}
+
+func TestAcceptParallel(t *testing.T) {
+	var as = assert.New(t)
+
+	l := NewConnLimiter()
+	as.NoError(l.Init(context.Background(), WithMaxConnsPerIP(5)))
+	defer l.Stop()
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Accept("1.1.1.1") {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	as.Equal(int32(5), accepted.Load())
+	as.Equal(5, l.ConnLen("1.1.1.1"))
+}
+
+func TestAllowMsg(t *testing.T) {
+	var as = assert.New(t)
+
+	dir := t.TempDir()
+	as.NoError(log.Init(context.Background(), log.WithInfoPath(dir), log.WithErrPath(dir)))
+
+	var violations []VIOLATION
+	l := NewConnLimiter()
+	as.NoError(l.Init(context.Background(),
+		WithMsgRate(MSG_CLASS_DEFAULT, 1, 2),
+		WithMsgRate("chat", 1, 1),
+		WithClassFn(func(msgID uint16) string {
+			if msgID == 100 {
+				return "chat"
+			}
+			return MSG_CLASS_DEFAULT
+		}),
+		WithActionFn(func(v *Violation) ACTION {
+			violations = append(violations, v.Kind)
+			if v.Class == "chat" {
+				return ACTION_BAN
+			}
+			return ACTION_DROP
+		}),
+	))
+	defer l.Stop()
+
+	ss := &testSession{}
+	ss.Set("ip", "3.3.3.3")
+	as.True(l.AllowMsg(ss, 1))
+	as.True(l.AllowMsg(ss, 2))
+	as.False(l.AllowMsg(ss, 1))
+	as.False(ss.closed)
+
+	as.True(l.AllowMsg(ss, 100))
+	as.False(l.AllowMsg(ss, 100))
+	as.True(ss.closed)
+	as.True(l.IsBanned("3.3.3.3"))
+	as.False(l.Accept("3.3.3.3"))
+	as.Contains(l.BanList(), "3.3.3.3")
+	as.Equal([]VIOLATION{VIOLATION_MSG_RATE, VIOLATION_MSG_RATE}, violations)
+
+	l.Unban("3.3.3.3")
+	as.True(l.Accept("3.3.3.3"))
+}
@@ -0,0 +1,49 @@
+package conn_limiter
+
+import (
+	"context"
+	"time"
+)
+
+var defLimiter *ConnLimiter
+
+func Init(ctx context.Context, opts ...Option) (err error) {
+	defLimiter = NewConnLimiter()
+	if err = defLimiter.Init(ctx, opts...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Get() *ConnLimiter {
+	return defLimiter
+}
+
+func Accept(ip string) bool {
+	return defLimiter.Accept(ip)
+}
+
+func Release(ip string) {
+	defLimiter.Release(ip)
+}
+
+func AllowMsg(ss Session, msgID uint16) bool {
+	return defLimiter.AllowMsg(ss, msgID)
+}
+
+func Ban(ip string, d time.Duration) {
+	defLimiter.Ban(ip, d)
+}
+
+func Unban(ip string) {
+	defLimiter.Unban(ip)
+}
+
+func IsBanned(ip string) bool {
+	return defLimiter.IsBanned(ip)
+}
+
+func BanList() map[string]time.Time {
+	return defLimiter.BanList()
+}
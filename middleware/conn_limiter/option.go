@@ -0,0 +1,97 @@
+package conn_limiter
+
+import (
+	"time"
+)
+
+// ActionFn 触发限制时调用, 返回值决定如何处理
+type ActionFn func(v *Violation) ACTION
+
+// ClassFn 返回 msgID 所属的限流分类
+type ClassFn func(msgID uint16) string
+
+type msgRate struct {
+	limit float64 // 每秒允许的消息数
+	burst int     // 突发容量
+}
+
+type LimiterOption struct {
+	maxConnsPerIP int
+	connRate      float64
+	connBurst     int
+
+	msgRates map[string]msgRate
+	classFn  ClassFn
+
+	action   ACTION
+	actionFn ActionFn
+
+	banDuration   time.Duration
+	sweepInterval time.Duration
+}
+
+type Option func(o *LimiterOption)
+
+func NewLimiterOption() *LimiterOption {
+	return &LimiterOption{
+		msgRates: make(map[string]msgRate),
+		classFn: func(uint16) string {
+			return MSG_CLASS_DEFAULT
+		},
+
+		action: ACTION_DROP,
+
+		banDuration:   BAN_DURATION,
+		sweepInterval: SWEEP_INTERVAL,
+	}
+}
+
+// WithMaxConnsPerIP 单个 IP 的最大并发连接数, 0 不限制
+func WithMaxConnsPerIP(n int) Option {
+	return func(o *LimiterOption) {
+		o.maxConnsPerIP = n
+	}
+}
+
+// WithConnRate 单个 IP 每秒允许的新连接数
+func WithConnRate(r float64, burst int) Option {
+	return func(o *LimiterOption) {
+		o.connRate = r
+		o.connBurst = burst
+	}
+}
+
+// WithMsgRate 每个会话中 class 分类消息的令牌桶, 未配置的分类使用 MSG_CLASS_DEFAULT
+func WithMsgRate(class string, r float64, burst int) Option {
+	return func(o *LimiterOption) {
+		o.msgRates[class] = msgRate{limit: r, burst: burst}
+	}
+}
+
+// WithClassFn msgID 的分类函数, 默认全部为 MSG_CLASS_DEFAULT
+func WithClassFn(fn ClassFn) Option {
+	return func(o *LimiterOption) {
+		o.classFn = fn
+	}
+}
+
+// WithAction 触发限制时的默认处理方式, 默认 ACTION_DROP
+func WithAction(action ACTION) Option {
+	return func(o *LimiterOption) {
+		o.action = action
+	}
+}
+
+// WithActionFn 自定义处理方式, 优先于 WithAction
+func WithActionFn(fn ActionFn) Option {
+	return func(o *LimiterOption) {
+		o.actionFn = fn
+	}
+}
+
+// WithBanDuration ACTION_BAN 的封禁时长
+func WithBanDuration(d time.Duration) Option {
+	return func(o *LimiterOption) {
+		o.banDuration = d
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"net/http"
 )
 
 var defTgBot *TgBot
@@ -39,4 +40,8 @@ func StartWebHook() {
 
 func ProcessUpdate(update *gotgbot.Update) error {
 	return defTgBot.ProcessUpdate(update)
-}
\ No newline at end of file
+}
+
+func WebhookHandler() http.Handler {
+	return defTgBot.WebhookHandler()
+}
@@ -0,0 +1,35 @@
+package go_tg_bot
+
+import (
+	"encoding/json"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+)
+
+// WebhookHandler 处理 Telegram 推送的 update, 可挂载到 mux 或通过 http_server.Handle 挂到 fiber
+func (t *TgBot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+
+		var update gotgbot.Update
+		if err = json.Unmarshal(body, &update); err != nil {
+			http.Error(w, "Error parsing JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err = t.ProcessUpdate(&update); err != nil {
+			log.Error("tg bot process update err:", zap.Error(err))
+			http.Error(w, "Error process update", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
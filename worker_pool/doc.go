@@ -2,8 +2,6 @@ package worker_pool
 
 import (
 	"context"
-	"github.com/v587-zyf/gc/gcnet/tcp_session"
-	"github.com/v587-zyf/gc/gcnet/ws_session"
 	"github.com/v587-zyf/gc/iface"
 	"time"
 )
@@ -27,12 +25,19 @@ func Assign(task iface.ITask) error {
 	return defaultWorkPoll.Assign(task)
 }
 
-func AssignWsTask(fn ws_session.Recv, ss iface.IWsSession, data any) error {
-	return defaultWorkPoll.AssignWsTask(fn, ss, data)
+func AssignSessionTask(fn iface.SessionRecv, ss iface.ISession, data any) error {
+	return defaultWorkPoll.AssignSessionTask(fn, ss, data)
 }
-func AssignTcpTask(fn tcp_session.Recv, ss iface.ITcpSession, data any) error {
-	return defaultWorkPoll.AssignTcpTask(fn, ss, data)
-}
-func AssignDelayTask(delay time.Duration, fn ws_session.Recv, ss iface.IWsSession, data any) error {
+func AssignDelayTask(delay time.Duration, fn iface.SessionRecv, ss iface.ISession, data any) error {
 	return defaultWorkPoll.AssignDelaySendTask(delay, fn, ss, data)
 }
+
+func AssignKeyed(key any, task iface.ITask) error {
+	return defaultWorkPoll.AssignKeyed(key, task)
+}
+func AssignKeyedFunc(key any, fn func()) error {
+	return defaultWorkPoll.AssignKeyedFunc(key, fn)
+}
+func AssignOrderedSessionTask(fn iface.SessionRecv, ss iface.ISession, data any) error {
+	return defaultWorkPoll.AssignOrderedSessionTask(fn, ss, data)
+}
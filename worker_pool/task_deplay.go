@@ -1,15 +1,14 @@
 package worker_pool
 
 import (
-	"github.com/v587-zyf/gc/gcnet/ws_session"
 	"github.com/v587-zyf/gc/iface"
 	"time"
 )
 
 type DelaySendTask struct {
 	Delay   time.Duration
-	Func    ws_session.Recv
-	Session iface.IWsSession
+	Func    iface.SessionRecv
+	Session iface.ISession
 	Data    any
 }
 
@@ -18,7 +17,7 @@ func (t *DelaySendTask) Do() {
 	t.Func(t.Session, t.Data)
 }
 
-func (p *WorkerPool) AssignDelaySendTask(delay time.Duration, fn ws_session.Recv, ss iface.IWsSession, data any) error {
+func (p *WorkerPool) AssignDelaySendTask(delay time.Duration, fn iface.SessionRecv, ss iface.ISession, data any) error {
 	return Assign(&DelaySendTask{
 		Delay:   delay,
 		Func:    fn,
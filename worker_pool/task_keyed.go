@@ -0,0 +1,114 @@
+package worker_pool
+
+import (
+	"github.com/v587-zyf/gc/iface"
+	"github.com/v587-zyf/gc/log"
+	"go.uber.org/zap"
+	"runtime/debug"
+)
+
+// keyedBatch 同一个 key 连续执行的最大任务数, 超过后让出 worker
+const keyedBatch = 64
+
+// keyedQueue 同一个 key 的待执行任务, 同一时刻只有一个 worker 在执行
+type keyedQueue struct {
+	key   any
+	tasks []iface.ITask
+}
+
+type keyedTask struct {
+	p *WorkerPool
+	q *keyedQueue
+}
+
+func (t *keyedTask) Do() {
+	p, q := t.p, t.q
+
+	for i := 0; i < keyedBatch; i++ {
+		p.keyedMu.Lock()
+		if len(q.tasks) == 0 {
+			delete(p.keyed, q.key)
+			p.keyedMu.Unlock()
+			return
+		}
+		task := q.tasks[0]
+		q.tasks[0] = nil
+		q.tasks = q.tasks[1:]
+		p.keyedMu.Unlock()
+
+		doKeyed(task)
+	}
+
+	p.keyedMu.Lock()
+	if len(q.tasks) == 0 {
+		delete(p.keyed, q.key)
+		p.keyedMu.Unlock()
+		return
+	}
+	p.keyedMu.Unlock()
+
+	p.runKeyed(q)
+}
+
+// doKeyed 单个任务 panic 不能影响同 key 后续任务
+func doKeyed(task iface.ITask) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("keyed task panic", zap.Any("r", r), zap.String("stack", string(debug.Stack())))
+		}
+	}()
+
+	task.Do()
+}
+
+// AssignKeyed 同一个 key 的任务按提交顺序串行执行, 不同 key 并行执行
+// key 可以是会话或玩家 ID, 用于保证同一玩家的消息按顺序处理
+func (p *WorkerPool) AssignKeyed(key any, task iface.ITask) error {
+	p.keyedMu.Lock()
+	if p.keyed == nil {
+		p.keyed = make(map[any]*keyedQueue)
+	}
+	if q, ok := p.keyed[key]; ok {
+		q.tasks = append(q.tasks, task)
+		p.keyedMu.Unlock()
+		return nil
+	}
+	q := &keyedQueue{key: key, tasks: []iface.ITask{task}}
+	p.keyed[key] = q
+	p.keyedMu.Unlock()
+
+	return p.runKeyed(q)
+}
+
+func (p *WorkerPool) runKeyed(q *keyedQueue) error {
+	err := p.Assign(&keyedTask{p: p, q: q})
+	if err != nil {
+		p.keyedMu.Lock()
+		delete(p.keyed, q.key)
+		dropped := len(q.tasks)
+		p.keyedMu.Unlock()
+
+		log.Warn("keyed task assign err", zap.Any("key", q.key), zap.Int("dropped", dropped), zap.Error(err))
+	}
+
+	return err
+}
+
+type FuncTask func()
+
+func (t FuncTask) Do() {
+	t()
+}
+
+func (p *WorkerPool) AssignKeyedFunc(key any, fn func()) error {
+	return p.AssignKeyed(key, FuncTask(fn))
+}
+
+// AssignOrderedSessionTask 同一会话的消息按顺序处理, 可用于 tcp_session.WithPoolDispatch(AssignOrderedSessionTask)
+func (p *WorkerPool) AssignOrderedSessionTask(fn iface.SessionRecv, ss iface.ISession, data any) error {
+	return p.AssignKeyed(ss, &SessionTask{
+		Func:    fn,
+		Session: ss,
+		Data:    data,
+	})
+}
@@ -0,0 +1,51 @@
+package worker_pool
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestAssignKeyed(t *testing.T) {
+	var as = assert.New(t)
+
+	p := NewWorkerPool()
+	as.NoError(p.Init(context.Background()))
+	p.Start()
+	defer p.Stop()
+
+	const (
+		keys  = 8
+		tasks = 500
+	)
+
+	var (
+		mu     sync.Mutex
+		result = make(map[int][]int)
+		wg     sync.WaitGroup
+	)
+	wg.Add(keys * tasks)
+	for i := 0; i < tasks; i++ {
+		for key := 0; key < keys; key++ {
+			key, i := key, i
+			as.NoError(p.AssignKeyedFunc(key, func() {
+				defer wg.Done()
+
+				mu.Lock()
+				result[key] = append(result[key], i)
+				mu.Unlock()
+			}))
+		}
+	}
+	wg.Wait()
+
+	for key := 0; key < keys; key++ {
+		as.Len(result[key], tasks)
+		as.IsIncreasing(result[key])
+	}
+
+	p.keyedMu.Lock()
+	as.Empty(p.keyed)
+	p.keyedMu.Unlock()
+}
@@ -0,0 +1,30 @@
+package worker_pool
+
+import (
+	"github.com/v587-zyf/gc/iface"
+)
+
+// AssignSessionTask tcp 和 ws 会话共用, 可用于 ws_session.WithDispatch(ws_session.DISPATCH_POOL, AssignSessionTask)
+func (p *WorkerPool) AssignSessionTask(fn iface.SessionRecv, ss iface.ISession, data any) error {
+	return p.Assign(&SessionTask{
+		Func:    fn,
+		Session: ss,
+		Data:    data,
+	})
+}
+
+type SessionTask struct {
+	Func    iface.SessionRecv
+	Session iface.ISession
+	Data    any
+}
+
+func (t *SessionTask) Do() {
+	if t.Func == nil {
+		if defaultWorkPoll.options.errHandler != nil {
+			defaultWorkPoll.options.errHandler(t.Data)
+		}
+		return
+	}
+	t.Func(t.Session, t.Data)
+}
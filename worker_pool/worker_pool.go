@@ -31,6 +31,9 @@ type WorkerPool struct {
 
 	ready         []*worker
 	idleCleanTime time.Duration
+
+	keyedMu sync.Mutex
+	keyed   map[any]*keyedQueue
 }
 
 func NewWorkerPool() *WorkerPool {
@@ -83,8 +86,12 @@ var workerCap = func() int {
 
 func (p *WorkerPool) Start() {
 	p.once.Do(func() {
-		p.stopCh = make(chan struct{})
+		// 循环使用局部的 stopCh, 避免与 Stop 并发读写字段
+		stopCh := make(chan struct{})
+		p.mu.Lock()
+		p.stopCh = stopCh
 		p.mustStop = false
+		p.mu.Unlock()
 
 		go tools.GoSafe("work_pool loop", func() {
 			timer := time.NewTicker(10 * time.Second)
@@ -94,7 +101,7 @@ func (p *WorkerPool) Start() {
 		LOOP:
 			for {
 				select {
-				case <-p.stopCh:
+				case <-stopCh:
 					break LOOP
 				case <-timer.C:
 					p.clean(&scratch)
@@ -114,12 +121,11 @@ func (p *WorkerPool) Stop() {
 	}
 
 	p.mu.Lock()
-	if p.stopCh == nil {
+	if p.stopCh == nil || p.mustStop {
 		p.mu.Unlock()
 		return
 	}
 	close(p.stopCh)
-	p.stopCh = nil
 	p.mustStop = true
 	ready := p.ready
 	p.ready = nil